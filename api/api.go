@@ -1,37 +1,41 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
-	"os"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/pooulad/blogo/internal/app"
+	"github.com/pooulad/blogo/internal/feed"
+	"github.com/pooulad/blogo/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 
 	"github.com/swaggo/files"
 	"github.com/swaggo/gin-swagger"
 )
 
-type logLevel int8
-
+// server timeouts. WriteTimeout leaves room for the slowest handlers like
+// search and feeds.
 const (
-	LogLevelDebug logLevel = iota
-	LogLevelInfo
-	LogLevelWarn
-	LogLevelError
-	logLevelFatal
-)
-
-var (
-	logg = log.New(os.Stdout, "", log.Ldate|log.Ltime)
+	readHeaderTimeout = 5 * time.Second
+	readTimeout       = 15 * time.Second
+	writeTimeout      = 30 * time.Second
+	idleTimeout       = 60 * time.Second
 )
 
 type api struct {
 	engine *gin.Engine
 	app    app.App
+	server *http.Server
+	// shuttingDown makes /readyz fail so load balancers stop sending traffic
+	// while in-flight requests drain.
+	shuttingDown atomic.Bool
 }
 
 func New(app app.App) *api {
@@ -39,12 +43,32 @@ func New(app app.App) *api {
 		engine: gin.New(),
 		app:    app,
 	}
+	registerValidations()
 	a.setupRoutes()
+
+	cfg := app.GetConfig()
+	a.server = &http.Server{
+		Addr:              fmt.Sprintf("%s:%s", cfg.AppUrl, cfg.Port),
+		Handler:           a.engine.Handler(),
+		ReadHeaderTimeout: readHeaderTimeout,
+		ReadTimeout:       readTimeout,
+		WriteTimeout:      writeTimeout,
+		IdleTimeout:       idleTimeout,
+	}
 	return a
 }
 
 func (a *api) setupRoutes() {
-	a.engine.Use(gin.Recovery())
+	a.engine.Use(requestID(), accessLog(), instrument(), gin.Recovery())
+
+	// unknown routes and methods get a problem like every other error
+	a.engine.HandleMethodNotAllowed = true
+	a.engine.NoRoute(func(ctx *gin.Context) {
+		problemResponse(ctx, app.NotFound("route"))
+	})
+	a.engine.NoMethod(func(ctx *gin.Context) {
+		problemResponse(ctx, &app.Error{Kind: app.ErrMethodNotAllowed, Code: "method_not_allowed", Message: "method is not allowed on this route"})
+	})
 
 	api := a.engine.Group("/api/v1")
 	{
@@ -52,46 +76,136 @@ func (a *api) setupRoutes() {
 		{
 			auth.POST("/login", a.Login)
 			auth.POST("/register", a.Register)
+			auth.POST("/refresh", a.Refresh)
+			auth.POST("/logout", a.jwtMiddleware(), a.authorize(app.PermSessionsManage), a.Logout)
+			auth.POST("/logout/all", a.jwtMiddleware(), a.authorize(app.PermSessionsManage), a.LogoutAll)
 		}
 		users := api.Group("/users")
-		// protect routes here with jwtMiddleware
-		users.Use(jwtMiddleware())
+		// public reads work without a token, everything else is checked by authorize
+		users.Use(a.optionalJwtMiddleware())
 		{
-			users.GET("", a.GetAllUsers)
+			users.GET("", a.authorize(app.PermUsersRead), a.GetAllUsers)
 			// create user by admin in panel
-			users.POST("/create", a.CreateUser)
-			users.GET("/get/:id", a.GetUserByID)
-			users.PATCH("/update/:id", a.UpdateUserByID)
-			users.DELETE("/delete/:id", a.DeleteUserByID)
-			users.POST("/follow", a.FollowUserByID)
-			users.POST("/unfollow", a.UnFollowUserByID)
-			users.GET("/followers/:id", a.GetFollowersByID)
-			users.GET("/following/:id", a.GetFollowingByID)
+			users.POST("/create", a.authorize(app.PermUsersCreate), a.CreateUser)
+			users.GET("/get/:id", a.authorize(app.PermUsersRead), a.GetUserByID)
+			// updating other users is checked again in app.UpdateUserByID
+			users.PATCH("/update/:id", a.authorize(app.PermUsersUpdate), a.UpdateUserByID)
+			users.DELETE("/delete/:id", a.authorize(app.PermUsersDelete), a.DeleteUserByID)
+			users.POST("/:id/follow", a.authorize(app.PermUsersFollow), a.FollowUserByID)
+			users.DELETE("/:id/follow", a.authorize(app.PermUsersFollow), a.UnFollowUserByID)
+			// deprecated: use POST and DELETE /users/:id/follow instead
+			users.POST("/follow", deprecated("/api/v1/users/:id/follow"), a.authorize(app.PermUsersFollow), a.FollowUserByID)
+			users.POST("/unfollow", deprecated("/api/v1/users/:id/follow"), a.authorize(app.PermUsersFollow), a.UnFollowUserByID)
+			users.GET("/followers/:id", a.authorize(app.PermUsersRead), a.GetFollowersByID)
+			users.GET("/following/:id", a.authorize(app.PermUsersRead), a.GetFollowingByID)
 		}
 		posts := api.Group("/posts")
+		// public reads work without a token, everything else is checked by authorize
+		posts.Use(a.optionalJwtMiddleware())
+		{
+			posts.GET("", a.authorize(app.PermPostsRead), a.GetAllPosts)
+			posts.POST("/create", a.authorize(app.PermPostsCreate), a.CreatePost)
+			posts.PATCH("/update/:id", a.authorize(app.PermPostsUpdate), a.UpdatePostByID)
+			posts.DELETE("/delete/:id", a.authorize(app.PermPostsDelete), a.DeletePostByID)
+			posts.GET("/get/:id", a.authorize(app.PermPostsRead), a.GetPostByID)
+			posts.GET("/by-slug/:slug", a.authorize(app.PermPostsRead), a.GetPostBySlug)
+			posts.POST("/:id/transfer", a.authorize(app.PermPostsTransfer), a.TransferPostByID)
+			posts.POST("/:id/tags", a.authorize(app.PermPostsUpdate), a.AttachTagsToPost)
+			posts.DELETE("/:id/tags/:slug", a.authorize(app.PermPostsUpdate), a.DetachTagFromPost)
+			posts.GET("/:id/comments", a.authorize(app.PermPostsRead), a.GetCommentsByPostID)
+			posts.POST("/:id/comments", a.authorize(app.PermCommentsCreate), a.CreateComment)
+			posts.PATCH("/:id/comments/:comment_id", a.authorize(app.PermCommentsCreate), a.UpdateCommentByID)
+			posts.DELETE("/:id/comments/:comment_id", a.authorize(app.PermCommentsCreate), a.DeleteCommentByID)
+			posts.POST("/:id/categories", a.authorize(app.PermPostsUpdate), a.AttachCategoriesToPost)
+			posts.DELETE("/:id/categories/:slug", a.authorize(app.PermPostsUpdate), a.DetachCategoryFromPost)
+			posts.GET("/:id/revisions", a.authorize(app.PermPostsUpdate), a.GetPostRevisions)
+			posts.GET("/:id/revisions/diff", a.authorize(app.PermPostsUpdate), a.DiffPostRevisions)
+			posts.POST("/:id/revisions/:number/restore", a.authorize(app.PermPostsUpdate), a.RestorePostRevision)
+			posts.POST("/:id/like", a.authorize(app.PermPostsLike), a.LikePostByID)
+			posts.DELETE("/:id/like", a.authorize(app.PermPostsLike), a.UnLikePostByID)
+			// deprecated: use POST and DELETE /posts/:id/like instead
+			posts.POST("/like", deprecated("/api/v1/posts/:id/like"), a.authorize(app.PermPostsLike), a.LikePostByID)
+			posts.POST("/unlike", deprecated("/api/v1/posts/:id/like"), a.authorize(app.PermPostsLike), a.UnLikePostByID)
+		}
+		feed := api.Group("/feed")
 		// protect routes here with jwtMiddleware
-		posts.Use(jwtMiddleware())
+		feed.Use(a.jwtMiddleware())
 		{
-			posts.GET("", a.GetAllPosts)
-			posts.POST("/create", a.CreatePost)
-			posts.PATCH("/update/:id", a.UpdatePostByID)
-			posts.DELETE("/delete/:id", a.DeletePostByID)
-			posts.GET("/get/:id", a.GetPostByID)
-			posts.POST("/like", a.LikePostByID)
-			posts.POST("/unlike", a.UnLikePostByID)
+			feed.GET("/home", a.authorize(app.PermPostsRead), a.GetHomeFeed)
+		}
+		tags := api.Group("/tags")
+		// public reads work without a token, everything else is checked by authorize
+		tags.Use(a.optionalJwtMiddleware())
+		{
+			tags.GET("", a.authorize(app.PermPostsRead), a.GetTagCounts)
+			tags.GET("/:slug/posts", a.authorize(app.PermPostsRead), a.GetPostsByTag)
+			tags.POST("/:slug/merge", a.authorize(app.PermTagsManage), a.MergeTags)
+		}
+		categories := api.Group("/categories")
+		// public reads work without a token, everything else is checked by authorize
+		categories.Use(a.optionalJwtMiddleware())
+		{
+			categories.GET("", a.authorize(app.PermPostsRead), a.GetCategoryCounts)
+			categories.POST("", a.authorize(app.PermCategoriesManage), a.CreateCategory)
+			categories.GET("/:slug/posts", a.authorize(app.PermPostsRead), a.GetPostsByCategory)
+		}
+		trash := api.Group("/trash")
+		// protect routes here with jwtMiddleware
+		trash.Use(a.jwtMiddleware())
+		{
+			trash.GET("/posts", a.authorize(app.PermTrashManage), a.GetTrashedPosts)
+			trash.GET("/users", a.authorize(app.PermTrashManage), a.GetTrashedUsers)
+			trash.POST("/posts/:id/restore", a.authorize(app.PermTrashManage), a.RestorePostByID)
+			trash.POST("/users/:id/restore", a.authorize(app.PermTrashManage), a.RestoreUserByID)
+			trash.DELETE("/posts/:id", a.authorize(app.PermTrashManage), a.PurgePostByID)
+			trash.DELETE("/users/:id", a.authorize(app.PermTrashManage), a.PurgeUserByID)
+		}
+		search := api.Group("/search")
+		// public reads work without a token, everything else is checked by authorize
+		search.Use(a.optionalJwtMiddleware())
+		{
+			search.GET("", a.authorize(app.PermPostsRead), a.Search)
+		}
+	}
+
+	// public feeds, readable without a token
+	feeds := a.engine.Group("/feeds")
+	{
+		for _, format := range []string{feed.FormatRSS, feed.FormatAtom, feed.FormatJSON} {
+			feeds.GET("/posts."+format, a.GetPostsFeed(format))
+			feeds.GET("/authors/:id/posts."+format, a.GetPostsFeed(format))
+			feeds.GET("/tags/:slug/posts."+format, a.GetPostsFeed(format))
 		}
 	}
 
+	// health probes for the orchestrator
+	a.engine.GET("/healthz", a.Healthz)
+	a.engine.GET("/readyz", a.Readyz)
+
+	// Prometheus metrics
+	a.engine.GET("/metrics", gin.WrapH(promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{})))
+
 	// Swagger endpoint
 	a.engine.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 }
 
 func (a *api) Start() error {
-	cfg := a.app.GetConfig()
-	addr := fmt.Sprintf("%s:%s", cfg.AppUrl, cfg.Port)
 	// Set all allowed proxies. I just set it to nil for now
 	a.engine.SetTrustedProxies(nil)
-	return a.engine.Run(addr)
+
+	slog.Info("server started", slog.String("addr", a.server.Addr))
+	if err := a.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+// Shutdown stops accepting new connections and waits for in-flight requests
+// until ctx is done.
+func (a *api) Shutdown(ctx context.Context) error {
+	a.shuttingDown.Store(true)
+	return a.server.Shutdown(ctx)
 }
 
 // writeJSON transforms the data into json format
@@ -107,7 +221,9 @@ func writeJSON(w http.ResponseWriter, status int, data interface{}, headers http
 		w.Header()[key] = value
 	}
 
-	w.Header().Set("Content-Type", "application/json")
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/json")
+	}
 	w.Header().Set("Date", time.Now().Format(http.TimeFormat))
 
 	w.WriteHeader(status)
@@ -115,49 +231,83 @@ func writeJSON(w http.ResponseWriter, status int, data interface{}, headers http
 	return nil
 }
 
-// errorResponse prints the error details to the log
-func errorResponse(w http.ResponseWriter, r *http.Request, status int, message interface{}) {
-	env := message
+// problem is the body of every failed request, see RFC 7807. Code is stable
+// and meant for machines, Detail is meant for humans.
+type problem struct {
+	Type      string           `json:"type"`
+	Title     string           `json:"title"`
+	Status    int              `json:"status"`
+	Detail    string           `json:"detail"`
+	Instance  string           `json:"instance"`
+	Code      string           `json:"code"`
+	Errors    []app.FieldError `json:"errors,omitempty"`
+	RequestID string           `json:"request_id,omitempty"`
+}
 
-	err := writeJSON(w, status, env, nil)
-	if err != nil {
-		logError(r, LogLevelError, err)
-		w.WriteHeader(500)
+// problemResponse writes err as an application/problem+json response. the
+// status comes from the kind of the domain error, any other error is an
+// internal error whose details only go to the log.
+func problemResponse(ctx *gin.Context, err error) {
+	status, p := http.StatusInternalServerError, problem{
+		Code:   "internal_error",
+		Detail: "internal server error",
 	}
-}
 
-// serverErrorResponse prints the error details to the log
-func serverErrorResponse(w http.ResponseWriter, r *http.Request, status int, err interface{}, logErr error) {
-	logError(r, LogLevelError, logErr)
-	errorResponse(w, r, status, err)
-}
+	if domainErr := app.AsError(err); domainErr != nil {
+		status = problemStatus(domainErr.Kind)
+		p.Code = domainErr.Code
+		p.Detail = domainErr.Message
+		p.Errors = domainErr.Fields
+	}
+
+	p.Type = "about:blank"
+	p.Title = http.StatusText(status)
+	p.Status = status
+	p.Instance = ctx.Request.URL.Path
+	p.RequestID = ctx.GetString("request_id")
 
-// logger is a custom logger which prints the details to the standard output
-func logger(level logLevel, message map[string]string) {
-	var messages string
-	for k, v := range message {
-		messages += fmt.Sprintf("%s: '%s'; ", k, v)
+	logError(ctx.Request, status, err)
+	err = writeJSON(ctx.Writer, status, p, http.Header{"Content-Type": {"application/problem+json"}})
+	if err != nil {
+		logError(ctx.Request, http.StatusInternalServerError, err)
+		ctx.Writer.WriteHeader(http.StatusInternalServerError)
 	}
+}
 
-	switch level {
-	case LogLevelDebug:
-		logg.Printf("\t[DEBUG] %s\n", messages)
-	case LogLevelInfo:
-		logg.Printf("\t[INFO] %s\n", messages)
-	case LogLevelWarn:
-		logg.Printf("\t[WARN] %s\n", messages)
-	case LogLevelError:
-		logg.Printf("\t[ERROR] %s\n", messages)
-	case logLevelFatal:
-		logg.Printf("\t[FATAL] %s\n", messages)
-		os.Exit(1)
+// problemStatus maps every kind of domain error to its status code.
+func problemStatus(kind error) int {
+	switch kind {
+	case app.ErrValidation:
+		return http.StatusBadRequest
+	case app.ErrUnauthorized:
+		return http.StatusUnauthorized
+	case app.ErrForbidden:
+		return http.StatusForbidden
+	case app.ErrNotFound:
+		return http.StatusNotFound
+	case app.ErrConflict:
+		return http.StatusConflict
+	case app.ErrUnavailable:
+		return http.StatusServiceUnavailable
+	case app.ErrMethodNotAllowed:
+		return http.StatusMethodNotAllowed
 	}
+
+	return http.StatusInternalServerError
 }
 
-func logError(r *http.Request, logLevel logLevel, err error) {
-	logger(logLevel, map[string]string{
-		"error":  err.Error(),
-		"method": r.Method,
-		"url":    r.URL.String(),
-	})
+// logError logs the error of a failed request. client errors are logged as
+// warnings, only server errors are errors.
+func logError(r *http.Request, status int, err error) {
+	level := slog.LevelWarn
+	if status >= http.StatusInternalServerError {
+		level = slog.LevelError
+	}
+
+	slog.Log(r.Context(), level, "request failed",
+		slog.String("method", r.Method),
+		slog.String("path", r.URL.Path),
+		slog.Int("status", status),
+		slog.Any("error", err),
+	)
 }
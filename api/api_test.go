@@ -0,0 +1,51 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestUnknownRoutesAreProblems(t *testing.T) {
+	a := &api{engine: gin.New()}
+	a.setupRoutes()
+
+	tests := []struct {
+		method     string
+		path       string
+		wantStatus int
+		wantCode   string
+		wantAllow  string
+	}{
+		{http.MethodGet, "/api/v1/nothing", http.StatusNotFound, "not_found", ""},
+		{http.MethodPut, "/healthz", http.StatusMethodNotAllowed, "method_not_allowed", http.MethodGet},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			a.engine.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if contentType := w.Header().Get("Content-Type"); contentType != "application/problem+json" {
+				t.Errorf("content type = %q, want application/problem+json", contentType)
+			}
+			if allow := w.Header().Get("Allow"); allow != tt.wantAllow {
+				t.Errorf("allow = %q, want %q", allow, tt.wantAllow)
+			}
+
+			var p problem
+			if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+				t.Fatal(err)
+			}
+			if p.Status != tt.wantStatus || p.Code != tt.wantCode || p.Instance != tt.path {
+				t.Errorf("problem = %+v", p)
+			}
+		})
+	}
+}
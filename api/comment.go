@@ -0,0 +1,183 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pooulad/blogo/internal/app"
+	"github.com/pooulad/blogo/internal/database/model"
+)
+
+// GetCommentsByPostID godoc
+// @Summary Get the comments of a post
+// @Description Retrieve every comment of a post as threads, oldest first. Deleted comments keep their place in the thread with hidden content
+// @Tags comments
+// @Produce json
+// @Param id path int true "Post ID"
+// @Success 200 {object} map[string]interface{} "Comment threads"
+// @Failure 400 {object} problem "Bad request error with message"
+// @Failure 500 {object} problem "Internal server error"
+// @Router /api/v1/posts/{id}/comments [get]
+func (a *api) GetCommentsByPostID(ctx *gin.Context) {
+	postID, err := getIntParam(ctx, "id")
+	if err != nil {
+		problemResponse(ctx, app.Invalid("id", "post id param is invalid"))
+		return
+	}
+
+	comments, err := a.app.GetCommentsByPostID(appContext(ctx), postID)
+	if err != nil {
+		problemResponse(ctx, err)
+		return
+	}
+
+	err = writeJSON(ctx.Writer, http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{
+			"success":  true,
+			"message":  "Get comments successful",
+			"comments": comments,
+		},
+	}, nil)
+	if err != nil {
+		problemResponse(ctx, err)
+		return
+	}
+}
+
+// CreateComment godoc
+// @Summary Comment on a post
+// @Description Create a comment on a post. Set parent_id to reply to another comment of the same post
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param commentInput body model.CommentInput true "Comment data"
+// @Success 200 {object} map[string]interface{} "Create comment successful"
+// @Failure 400 {object} problem "Bad request error with message"
+// @Failure 500 {object} problem "Internal server error"
+// @Router /api/v1/posts/{id}/comments [post]
+func (a *api) CreateComment(ctx *gin.Context) {
+	postID, err := getIntParam(ctx, "id")
+	if err != nil {
+		problemResponse(ctx, app.Invalid("id", "post id param is invalid"))
+		return
+	}
+
+	var commentInput model.CommentInput
+	if err := ctx.ShouldBindJSON(&commentInput); err != nil {
+		problemResponse(ctx, badRequest(err))
+		return
+	}
+
+	comment, err := a.app.CreateComment(appContext(ctx), postID, commentInput)
+	if err != nil {
+		problemResponse(ctx, err)
+		return
+	}
+
+	err = writeJSON(ctx.Writer, http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{
+			"success": true,
+			"message": "Create comment successful",
+			"comment": comment,
+		},
+	}, nil)
+	if err != nil {
+		problemResponse(ctx, err)
+		return
+	}
+}
+
+// UpdateCommentByID godoc
+// @Summary Edit a comment
+// @Description Edit the content of a comment. Only the author of the comment can edit it
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param comment_id path int true "Comment ID"
+// @Param commentInput body model.CommentInput true "Comment data"
+// @Success 200 {object} map[string]interface{} "Update comment successful"
+// @Failure 400 {object} problem "Bad request error with message"
+// @Failure 403 {object} problem "Comment belongs to another user"
+// @Failure 500 {object} problem "Internal server error"
+// @Router /api/v1/posts/{id}/comments/{comment_id} [patch]
+func (a *api) UpdateCommentByID(ctx *gin.Context) {
+	postID, err := getIntParam(ctx, "id")
+	if err != nil {
+		problemResponse(ctx, app.Invalid("id", "post id param is invalid"))
+		return
+	}
+
+	commentID, err := getIntParam(ctx, "comment_id")
+	if err != nil {
+		problemResponse(ctx, app.Invalid("comment_id", "comment id param is invalid"))
+		return
+	}
+
+	var commentInput model.CommentInput
+	if err := ctx.ShouldBindJSON(&commentInput); err != nil {
+		problemResponse(ctx, badRequest(err))
+		return
+	}
+
+	err = a.app.UpdateCommentByID(appContext(ctx), postID, commentID, commentInput)
+	if err != nil {
+		problemResponse(ctx, err)
+		return
+	}
+
+	err = writeJSON(ctx.Writer, http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{
+			"success": true,
+			"message": "Update comment successful",
+		},
+	}, nil)
+	if err != nil {
+		problemResponse(ctx, err)
+		return
+	}
+}
+
+// DeleteCommentByID godoc
+// @Summary Delete a comment
+// @Description Delete a comment. Its replies stay in the thread. Authors can delete their comments and editors can delete any comment
+// @Tags comments
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param comment_id path int true "Comment ID"
+// @Success 200 {object} map[string]interface{} "Delete comment successful"
+// @Failure 400 {object} problem "Bad request error with message"
+// @Failure 403 {object} problem "Comment belongs to another user"
+// @Failure 500 {object} problem "Internal server error"
+// @Router /api/v1/posts/{id}/comments/{comment_id} [delete]
+func (a *api) DeleteCommentByID(ctx *gin.Context) {
+	postID, err := getIntParam(ctx, "id")
+	if err != nil {
+		problemResponse(ctx, app.Invalid("id", "post id param is invalid"))
+		return
+	}
+
+	commentID, err := getIntParam(ctx, "comment_id")
+	if err != nil {
+		problemResponse(ctx, app.Invalid("comment_id", "comment id param is invalid"))
+		return
+	}
+
+	err = a.app.DeleteCommentByID(appContext(ctx), postID, commentID)
+	if err != nil {
+		problemResponse(ctx, err)
+		return
+	}
+
+	err = writeJSON(ctx.Writer, http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{
+			"success": true,
+			"message": "Delete comment successful",
+		},
+	}, nil)
+	if err != nil {
+		problemResponse(ctx, err)
+		return
+	}
+}
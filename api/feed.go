@@ -0,0 +1,146 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pooulad/blogo/internal/app"
+	"github.com/pooulad/blogo/internal/database/model"
+	"github.com/pooulad/blogo/internal/feed"
+)
+
+// GetHomeFeed godoc
+// @Summary Get the home feed
+// @Description Retrieve the published posts of the users the current user follows, newest first
+// @Tags feed
+// @Produce json
+// @Param limit query int false "Page size, 20 by default and 100 at most"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param sort query string false "newest (default) or oldest"
+// @Success 200 {object} map[string]interface{} "Posts of followed users"
+// @Failure 400 {object} problem "Bad request error with message"
+// @Failure 500 {object} problem "Internal server error"
+// @Router /api/v1/feed/home [get]
+func (a *api) GetHomeFeed(ctx *gin.Context) {
+	var query model.PageQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		problemResponse(ctx, badRequest(err))
+		return
+	}
+
+	posts, page, err := a.app.GetHomeFeed(appContext(ctx), query)
+	if err != nil {
+		problemResponse(ctx, err)
+		return
+	}
+
+	err = writeJSON(ctx.Writer, http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{
+			"success":     true,
+			"message":     "Get home feed successful",
+			"posts":       posts,
+			"next_cursor": page.NextCursor,
+			"has_more":    page.HasMore,
+		},
+	}, nil)
+	if err != nil {
+		problemResponse(ctx, err)
+		return
+	}
+}
+
+// GetPostsFeed godoc
+// @Summary Get the public posts feed
+// @Description Retrieve the latest published posts as RSS 2.0, Atom 1.0 or JSON Feed 1.1, for the whole site, an author or a tag. Supports conditional GET with If-None-Match and If-Modified-Since
+// @Tags feeds
+// @Produce xml
+// @Produce json
+// @Param id path int false "Author ID"
+// @Param slug path string false "Tag slug"
+// @Success 200 {string} string "Feed document"
+// @Success 304 {string} string "Feed hasn't changed"
+// @Failure 404 {object} problem "Author or tag not found"
+// @Failure 500 {object} problem "Internal server error"
+// @Router /feeds/posts.rss [get]
+// @Router /feeds/posts.atom [get]
+// @Router /feeds/posts.json [get]
+// @Router /feeds/authors/{id}/posts.rss [get]
+// @Router /feeds/authors/{id}/posts.atom [get]
+// @Router /feeds/authors/{id}/posts.json [get]
+// @Router /feeds/tags/{slug}/posts.rss [get]
+// @Router /feeds/tags/{slug}/posts.atom [get]
+// @Router /feeds/tags/{slug}/posts.json [get]
+func (a *api) GetPostsFeed(format string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var query model.FeedQuery
+
+		if ctx.Param("id") != "" {
+			authorID, err := GetParamByName(ctx, "id")
+			if err != nil {
+				problemResponse(ctx, app.Invalid("id", "author id param is invalid"))
+				return
+			}
+
+			id, ok := authorID.(int)
+			if !ok || id <= 0 {
+				problemResponse(ctx, app.Invalid("id", "author id param is invalid"))
+				return
+			}
+			query.AuthorID = uint(id)
+		}
+		query.Tag = ctx.Param("slug")
+
+		f, err := a.app.GetPostsFeed(appContext(ctx), query)
+		if err != nil {
+			problemResponse(ctx, err)
+			return
+		}
+		f.Self = a.app.GetConfig().PublicUrl + ctx.Request.URL.Path
+
+		body, err := feed.Encode(f, format)
+		if err != nil {
+			problemResponse(ctx, err)
+			return
+		}
+
+		sum := sha256.Sum256(body)
+		etag := fmt.Sprintf(`"%s"`, hex.EncodeToString(sum[:16]))
+		ctx.Header("ETag", etag)
+		ctx.Header("Last-Modified", f.Updated.UTC().Format(http.TimeFormat))
+		ctx.Header("Cache-Control", "public, max-age=300")
+
+		if isNotModified(ctx.Request, etag, f.Updated) {
+			ctx.Status(http.StatusNotModified)
+			return
+		}
+
+		ctx.Data(http.StatusOK, feed.ContentType(format), body)
+	}
+}
+
+// isNotModified reports whether the client already has the current version
+// of the feed. If-None-Match wins over If-Modified-Since, as in RFC 9110.
+func isNotModified(r *http.Request, etag string, updated time.Time) bool {
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		for _, candidate := range strings.Split(match, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == etag || candidate == "*" {
+				return true
+			}
+		}
+		return false
+	}
+
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+
+	// http dates have no sub second precision
+	return !updated.Truncate(time.Second).After(since)
+}
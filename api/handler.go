@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/pooulad/blogo/internal/app"
 	"github.com/pooulad/blogo/internal/database/model"
 )
 
@@ -14,35 +15,36 @@ import (
 // @Tags users
 // @Accept json
 // @Produce json
+// @Param limit query int false "Page size, 20 by default and 100 at most"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param sort query string false "newest (default) or oldest"
+// @Param role query string false "Filter by role"
+// @Param active query bool false "Filter by active flag"
 // @Success 200 {object} map[string]interface{} "Success response containing users"
-// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Failure 500 {object} problem "Internal server error"
 // @Router /api/v1/users [get]
 func (a *api) GetAllUsers(ctx *gin.Context) {
-	users, err := a.app.GetAllUsers(ctx)
+	var query model.UserQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		problemResponse(ctx, badRequest(err))
+		return
+	}
+
+	users, page, err := a.app.GetAllUsers(appContext(ctx), query)
 	if err != nil {
-		serverErrorResponse(ctx.Writer, ctx.Request, http.StatusInternalServerError, map[string]interface{}{
-			"data": map[string]interface{}{
-				"success": false,
-				"message": "get users failed",
-				"error":   err.Error(),
-			},
-		}, err)
+		problemResponse(ctx, err)
 		return
 	}
 
 	err = writeJSON(ctx.Writer, http.StatusOK, map[string]interface{}{
 		"data": map[string]interface{}{
-			"users": users,
+			"users":       users,
+			"next_cursor": page.NextCursor,
+			"has_more":    page.HasMore,
 		},
 	}, nil)
 	if err != nil {
-		serverErrorResponse(ctx.Writer, ctx.Request, http.StatusInternalServerError, map[string]interface{}{
-			"data": map[string]interface{}{
-				"success": false,
-				"message": "get users failed",
-				"error":   err.Error(),
-			},
-		}, err)
+		problemResponse(ctx, err)
 		return
 	}
 }
@@ -55,34 +57,20 @@ func (a *api) GetAllUsers(ctx *gin.Context) {
 // @Produce json
 // @Param user body model.User true "User details"
 // @Success 200 {object} map[string]interface{} "Success response"
-// @Failure 400 {object} map[string]interface{} "Bad request or validation error"
-// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Failure 400 {object} problem "Bad request or validation error"
+// @Failure 500 {object} problem "Internal server error"
 // @Router /api/v1/users [post]
 func (a *api) CreateUser(ctx *gin.Context) {
 	var user model.User
 
 	if err := ctx.ShouldBindJSON(&user); err != nil {
-		serverErrorResponse(ctx.Writer, ctx.Request, http.StatusBadRequest, map[string]interface{}{
-			"data": map[string]interface{}{
-				"success": false,
-				"message": "Create user failed",
-				"error":   err.Error(),
-			},
-		}, err)
+		problemResponse(ctx, badRequest(err))
 		return
 	}
 
-	fmt.Println(user.Password)
-
-	err := a.app.CreateUser(ctx, &user)
+	err := a.app.CreateUser(appContext(ctx), &user)
 	if err != nil {
-		serverErrorResponse(ctx.Writer, ctx.Request, http.StatusBadRequest, map[string]interface{}{
-			"data": map[string]interface{}{
-				"success": false,
-				"message": "Create user failed",
-				"error":   err.Error(),
-			},
-		}, err)
+		problemResponse(ctx, err)
 		return
 	}
 
@@ -93,13 +81,7 @@ func (a *api) CreateUser(ctx *gin.Context) {
 		},
 	}, nil)
 	if err != nil {
-		serverErrorResponse(ctx.Writer, ctx.Request, http.StatusInternalServerError, map[string]interface{}{
-			"data": map[string]interface{}{
-				"success": false,
-				"message": "Create user failed",
-				"error":   err.Error(),
-			},
-		}, err)
+		problemResponse(ctx, err)
 		return
 	}
 }
@@ -112,31 +94,19 @@ func (a *api) CreateUser(ctx *gin.Context) {
 // @Produce json
 // @Param id path int true "User ID"
 // @Success 200 {object} map[string]interface{} "Success response"
-// @Failure 400 {object} map[string]interface{} "Bad request or invalid user ID"
-// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Failure 400 {object} problem "Bad request or invalid user ID"
+// @Failure 500 {object} problem "Internal server error"
 // @Router /api/v1/users/{id} [delete]
 func (a *api) DeleteUserByID(ctx *gin.Context) {
-	userID, err := GetParamByName(ctx, "id")
+	userID, err := getIntParam(ctx, "id")
 	if err != nil {
-		serverErrorResponse(ctx.Writer, ctx.Request, http.StatusBadRequest, map[string]interface{}{
-			"data": map[string]interface{}{
-				"success": false,
-				"message": "Delete user failed",
-				"error":   fmt.Errorf("user id param is invalid").Error(),
-			},
-		}, fmt.Errorf("user id param is invalid"))
+		problemResponse(ctx, app.Invalid("id", "user id param is invalid"))
 		return
 	}
 
-	err = a.app.DeleteUserByID(ctx, userID.(int))
+	err = a.app.DeleteUserByID(appContext(ctx), userID)
 	if err != nil {
-		serverErrorResponse(ctx.Writer, ctx.Request, http.StatusBadRequest, map[string]interface{}{
-			"data": map[string]interface{}{
-				"success": false,
-				"message": "Delete user failed",
-				"error":   err.Error(),
-			},
-		}, err)
+		problemResponse(ctx, err)
 		return
 	}
 
@@ -147,13 +117,7 @@ func (a *api) DeleteUserByID(ctx *gin.Context) {
 		},
 	}, nil)
 	if err != nil {
-		serverErrorResponse(ctx.Writer, ctx.Request, http.StatusInternalServerError, map[string]interface{}{
-			"data": map[string]interface{}{
-				"success": false,
-				"message": "Delete user failed",
-				"error":   err.Error(),
-			},
-		}, err)
+		problemResponse(ctx, err)
 		return
 	}
 }
@@ -166,31 +130,19 @@ func (a *api) DeleteUserByID(ctx *gin.Context) {
 // @Produce json
 // @Param id path int true "User ID"
 // @Success 200 {object} map[string]interface{} "Success response with user details"
-// @Failure 400 {object} map[string]interface{} "Bad request or invalid user ID"
-// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Failure 400 {object} problem "Bad request or invalid user ID"
+// @Failure 500 {object} problem "Internal server error"
 // @Router /api/v1/users/{id} [get]
 func (a *api) GetUserByID(ctx *gin.Context) {
-	userID, err := GetParamByName(ctx, "id")
+	userID, err := getIntParam(ctx, "id")
 	if err != nil {
-		serverErrorResponse(ctx.Writer, ctx.Request, http.StatusBadRequest, map[string]interface{}{
-			"data": map[string]interface{}{
-				"success": false,
-				"message": "Get user by id failed",
-				"error":   fmt.Errorf("user id param is invalid").Error(),
-			},
-		}, fmt.Errorf("user id param is invalid"))
+		problemResponse(ctx, app.Invalid("id", "user id param is invalid"))
 		return
 	}
 
-	user, err := a.app.GetUserByID(ctx, userID.(int))
+	user, err := a.app.GetUserByID(appContext(ctx), userID)
 	if err != nil {
-		serverErrorResponse(ctx.Writer, ctx.Request, http.StatusBadRequest, map[string]interface{}{
-			"data": map[string]interface{}{
-				"success": false,
-				"message": "Get user by id failed",
-				"error":   err.Error(),
-			},
-		}, err)
+		problemResponse(ctx, err)
 		return
 	}
 
@@ -202,13 +154,7 @@ func (a *api) GetUserByID(ctx *gin.Context) {
 		},
 	}, nil)
 	if err != nil {
-		serverErrorResponse(ctx.Writer, ctx.Request, http.StatusInternalServerError, map[string]interface{}{
-			"data": map[string]interface{}{
-				"success": false,
-				"message": "Get user by id failed",
-				"error":   err.Error(),
-			},
-		}, err)
+		problemResponse(ctx, err)
 		return
 	}
 }
@@ -220,33 +166,28 @@ func (a *api) GetUserByID(ctx *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param id path int true "User ID"
-// @Param user body map[string]interface{} true "Updated user data"
+// @Param user body model.UpdateUserInput true "Updated user data"
 // @Success 200 {object} map[string]interface{} "Success response"
-// @Failure 400 {object} map[string]interface{} "Bad request or invalid user ID"
-// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Failure 400 {object} problem "Bad request or invalid user ID"
+// @Failure 403 {object} problem "Updating another user, role or active requires admin"
+// @Failure 500 {object} problem "Internal server error"
 // @Router /api/v1/users/{id} [patch]
 func (a *api) UpdateUserByID(ctx *gin.Context) {
-	userID, err := GetParamByName(ctx, "id")
+	userID, err := getIntParam(ctx, "id")
 	if err != nil {
-		serverErrorResponse(ctx.Writer, ctx.Request, http.StatusBadRequest, map[string]interface{}{
-			"data": map[string]interface{}{
-				"success": false,
-				"message": "Update user failed",
-				"error":   fmt.Errorf("user id param is invalid").Error(),
-			},
-		}, fmt.Errorf("user id param is invalid"))
+		problemResponse(ctx, app.Invalid("id", "user id param is invalid"))
+		return
+	}
+
+	var input model.UpdateUserInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		problemResponse(ctx, badRequest(err))
 		return
 	}
 
-	err = a.app.UpdateUserByID(ctx, userID.(int))
+	err = a.app.UpdateUserByID(appContext(ctx), userID, input)
 	if err != nil {
-		serverErrorResponse(ctx.Writer, ctx.Request, http.StatusBadRequest, map[string]interface{}{
-			"data": map[string]interface{}{
-				"success": false,
-				"message": "Update user failed",
-				"error":   err.Error(),
-			},
-		}, err)
+		problemResponse(ctx, err)
 		return
 	}
 
@@ -257,13 +198,7 @@ func (a *api) UpdateUserByID(ctx *gin.Context) {
 		},
 	}, nil)
 	if err != nil {
-		serverErrorResponse(ctx.Writer, ctx.Request, http.StatusInternalServerError, map[string]interface{}{
-			"data": map[string]interface{}{
-				"success": false,
-				"message": "Update user failed",
-				"error":   err.Error(),
-			},
-		}, err)
+		problemResponse(ctx, err)
 		return
 	}
 }
@@ -275,50 +210,43 @@ func (a *api) UpdateUserByID(ctx *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param id path int true "User ID"
+// @Param limit query int false "Page size, 20 by default and 100 at most"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param sort query string false "newest (default) or oldest"
 // @Success 200 {object} map[string]interface{} "Success response with followers list"
-// @Failure 400 {object} map[string]interface{} "Bad request or invalid user ID"
-// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Failure 400 {object} problem "Bad request or invalid user ID"
+// @Failure 500 {object} problem "Internal server error"
 // @Router /api/v1/users/{id}/followers [get]
 func (a *api) GetFollowersByID(ctx *gin.Context) {
-	userID, err := GetParamByName(ctx, "id")
+	userID, err := getIntParam(ctx, "id")
 	if err != nil {
-		serverErrorResponse(ctx.Writer, ctx.Request, http.StatusBadRequest, map[string]interface{}{
-			"data": map[string]interface{}{
-				"success": false,
-				"message": "Get followers by id failed",
-				"error":   fmt.Errorf("user id param is invalid").Error(),
-			},
-		}, fmt.Errorf("user id param is invalid"))
+		problemResponse(ctx, app.Invalid("id", "user id param is invalid"))
+		return
+	}
+
+	var query model.PageQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		problemResponse(ctx, badRequest(err))
 		return
 	}
 
-	followers, err := a.app.GetFollowersByID(ctx, userID.(int))
+	followers, page, err := a.app.GetFollowersByID(appContext(ctx), userID, query)
 	if err != nil {
-		serverErrorResponse(ctx.Writer, ctx.Request, http.StatusBadRequest, map[string]interface{}{
-			"data": map[string]interface{}{
-				"success": false,
-				"message": "Get followers by id failed",
-				"error":   err.Error(),
-			},
-		}, err)
+		problemResponse(ctx, err)
 		return
 	}
 
 	err = writeJSON(ctx.Writer, http.StatusOK, map[string]interface{}{
 		"data": map[string]interface{}{
-			"success":   true,
-			"message":   "Get followers by id successful",
-			"followers": followers,
+			"success":     true,
+			"message":     "Get followers by id successful",
+			"followers":   followers,
+			"next_cursor": page.NextCursor,
+			"has_more":    page.HasMore,
 		},
 	}, nil)
 	if err != nil {
-		serverErrorResponse(ctx.Writer, ctx.Request, http.StatusInternalServerError, map[string]interface{}{
-			"data": map[string]interface{}{
-				"success": false,
-				"message": "Get followers by id failed",
-				"error":   err.Error(),
-			},
-		}, err)
+		problemResponse(ctx, err)
 		return
 	}
 }
@@ -330,75 +258,69 @@ func (a *api) GetFollowersByID(ctx *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param id path int true "User ID"
+// @Param limit query int false "Page size, 20 by default and 100 at most"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param sort query string false "newest (default) or oldest"
 // @Success 200 {object} map[string]interface{} "Success response with following list"
-// @Failure 400 {object} map[string]interface{} "Bad request or invalid user ID"
-// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Failure 400 {object} problem "Bad request or invalid user ID"
+// @Failure 500 {object} problem "Internal server error"
 // @Router /api/v1/users/{id}/following [get]
 func (a *api) GetFollowingByID(ctx *gin.Context) {
-	userID, err := GetParamByName(ctx, "id")
+	userID, err := getIntParam(ctx, "id")
 	if err != nil {
-		serverErrorResponse(ctx.Writer, ctx.Request, http.StatusBadRequest, map[string]interface{}{
-			"data": map[string]interface{}{
-				"success": false,
-				"message": "Get following by id failed",
-				"error":   fmt.Errorf("user id param is invalid").Error(),
-			},
-		}, fmt.Errorf("user id param is invalid"))
+		problemResponse(ctx, app.Invalid("id", "user id param is invalid"))
 		return
 	}
 
-	following, err := a.app.GetFollowingByID(ctx, userID.(int))
+	var query model.PageQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		problemResponse(ctx, badRequest(err))
+		return
+	}
+
+	following, page, err := a.app.GetFollowingByID(appContext(ctx), userID, query)
 	if err != nil {
-		serverErrorResponse(ctx.Writer, ctx.Request, http.StatusBadRequest, map[string]interface{}{
-			"data": map[string]interface{}{
-				"success": false,
-				"message": "Get following by id failed",
-				"error":   err.Error(),
-			},
-		}, err)
+		problemResponse(ctx, err)
 		return
 	}
 
 	err = writeJSON(ctx.Writer, http.StatusOK, map[string]interface{}{
 		"data": map[string]interface{}{
-			"success":   true,
-			"message":   "Get following by id successful",
-			"following": following,
+			"success":     true,
+			"message":     "Get following by id successful",
+			"following":   following,
+			"next_cursor": page.NextCursor,
+			"has_more":    page.HasMore,
 		},
 	}, nil)
 	if err != nil {
-		serverErrorResponse(ctx.Writer, ctx.Request, http.StatusInternalServerError, map[string]interface{}{
-			"data": map[string]interface{}{
-				"success": false,
-				"message": "Get following by id failed",
-				"error":   err.Error(),
-			},
-		}, err)
+		problemResponse(ctx, err)
 		return
 	}
 }
 
 // FollowUserByID godoc
 // @Summary Follow a user by their ID
-// @Description Allows a user to follow another user by providing the target user's unique ID
+// @Description Makes the authenticated user follow the user with the given unique ID
 // @Tags following
 // @Accept json
 // @Produce json
 // @Param id path int true "Target User ID"
 // @Success 200 {object} map[string]interface{} "Success response when follow action is successful"
-// @Failure 400 {object} map[string]interface{} "Bad request or invalid input"
-// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Failure 400 {object} problem "Bad request, invalid input or self follow"
+// @Failure 409 {object} problem "User is already followed"
+// @Failure 500 {object} problem "Internal server error"
 // @Router /api/v1/users/{id}/follow [post]
 func (a *api) FollowUserByID(ctx *gin.Context) {
-	err := a.app.FollowUserByID(ctx)
+	targetID, err := getTargetID(ctx, "followed_id")
 	if err != nil {
-		serverErrorResponse(ctx.Writer, ctx.Request, http.StatusBadRequest, map[string]interface{}{
-			"data": map[string]interface{}{
-				"success": false,
-				"message": "Follow user failed",
-				"error":   err.Error(),
-			},
-		}, err)
+		problemResponse(ctx, badRequest(err))
+		return
+	}
+
+	err = a.app.FollowUserByID(appContext(ctx), targetID)
+	if err != nil {
+		problemResponse(ctx, err)
 		return
 	}
 
@@ -409,38 +331,32 @@ func (a *api) FollowUserByID(ctx *gin.Context) {
 		},
 	}, nil)
 	if err != nil {
-		serverErrorResponse(ctx.Writer, ctx.Request, http.StatusInternalServerError, map[string]interface{}{
-			"data": map[string]interface{}{
-				"success": false,
-				"message": "Follow user failed",
-				"error":   err.Error(),
-			},
-		}, err)
+		problemResponse(ctx, err)
 		return
 	}
 }
 
 // UnFollowUserByID godoc
 // @Summary Unfollow a user by their ID
-// @Description Allows a user to unfollow another user by providing the target user's unique ID
+// @Description Makes the authenticated user unfollow the user with the given unique ID
 // @Tags following
 // @Accept json
 // @Produce json
 // @Param id path int true "Target User ID"
 // @Success 200 {object} map[string]interface{} "Success response when unfollow action is successful"
-// @Failure 400 {object} map[string]interface{} "Bad request or invalid input"
-// @Failure 500 {object} map[string]interface{} "Internal server error"
-// @Router /api/v1/users/{id}/unfollow [post]
+// @Failure 400 {object} problem "Bad request or invalid input"
+// @Failure 500 {object} problem "Internal server error"
+// @Router /api/v1/users/{id}/follow [delete]
 func (a *api) UnFollowUserByID(ctx *gin.Context) {
-	err := a.app.UnFollowUserByID(ctx)
+	targetID, err := getTargetID(ctx, "followed_id")
 	if err != nil {
-		serverErrorResponse(ctx.Writer, ctx.Request, http.StatusBadRequest, map[string]interface{}{
-			"data": map[string]interface{}{
-				"success": false,
-				"message": "Unfollow user failed",
-				"error":   err.Error(),
-			},
-		}, err)
+		problemResponse(ctx, badRequest(err))
+		return
+	}
+
+	err = a.app.UnFollowUserByID(appContext(ctx), targetID)
+	if err != nil {
+		problemResponse(ctx, err)
 		return
 	}
 
@@ -451,90 +367,81 @@ func (a *api) UnFollowUserByID(ctx *gin.Context) {
 		},
 	}, nil)
 	if err != nil {
-		serverErrorResponse(ctx.Writer, ctx.Request, http.StatusInternalServerError, map[string]interface{}{
-			"data": map[string]interface{}{
-				"success": false,
-				"message": "Unfollow user failed",
-				"error":   err.Error(),
-			},
-		}, err)
+		problemResponse(ctx, err)
 		return
 	}
 }
 
 // GetAllPosts godoc
 // @Summary Get all posts
-// @Description Retrieve all posts from the application
+// @Description Retrieve all published posts, plus the unpublished posts of the current user
 // @Tags posts
 // @Accept json
 // @Produce json
+// @Param limit query int false "Page size, 20 by default and 100 at most"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param sort query string false "newest (default) or oldest"
+// @Param author_id query int false "Filter by author"
+// @Param from query string false "Only posts created at or after this RFC 3339 time"
+// @Param to query string false "Only posts created before this RFC 3339 time"
+// @Param liked_by_me query bool false "Only posts liked by the current user"
 // @Success 200 {object} map[string]interface{} "Success response with a list of posts"
-// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Failure 500 {object} problem "Internal server error"
 // @Router /api/v1/posts [get]
 func (a *api) GetAllPosts(ctx *gin.Context) {
-	posts, err := a.app.GetAllPosts(ctx)
+	var query model.PostQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		problemResponse(ctx, badRequest(err))
+		return
+	}
+
+	a.writePosts(ctx, query)
+}
+
+// writePosts writes a page of the posts matching the query.
+func (a *api) writePosts(ctx *gin.Context, query model.PostQuery) {
+	posts, page, err := a.app.GetAllPosts(appContext(ctx), query)
 	if err != nil {
-		serverErrorResponse(ctx.Writer, ctx.Request, http.StatusInternalServerError, map[string]interface{}{
-			"data": map[string]interface{}{
-				"success": false,
-				"message": "get posts failed",
-				"error":   err.Error(),
-			},
-		}, err)
+		problemResponse(ctx, err)
 		return
 	}
 
 	err = writeJSON(ctx.Writer, http.StatusOK, map[string]interface{}{
 		"data": map[string]interface{}{
-			"posts": posts,
+			"posts":       posts,
+			"next_cursor": page.NextCursor,
+			"has_more":    page.HasMore,
 		},
 	}, nil)
 	if err != nil {
-		serverErrorResponse(ctx.Writer, ctx.Request, http.StatusInternalServerError, map[string]interface{}{
-			"data": map[string]interface{}{
-				"success": false,
-				"message": "get posts failed",
-				"error":   err.Error(),
-			},
-		}, err)
+		problemResponse(ctx, err)
 		return
 	}
 }
 
 // CreatePost godoc
 // @Summary Create a new post
-// @Description Create a new post with the given data
+// @Description Create a new post with the given data, authored by the current user. Status is one of draft, scheduled, published or archived and defaults to published. Scheduled posts need a future published_at. Format is markdown (default) or html, the content is rendered to sanitized HTML in content_html, where every id and link fragment starts with user-content-. The slug is generated from the title unless one is given
 // @Tags posts
 // @Accept json
 // @Produce json
 // @Param post body model.Post true "Post data"
 // @Success 200 {object} map[string]interface{} "Success message for post creation"
-// @Failure 400 {object} map[string]interface{} "Bad request error with message"
-// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Failure 400 {object} problem "Bad request error with message"
+// @Failure 409 {object} problem "Slug is already taken"
+// @Failure 500 {object} problem "Internal server error"
 // @Router /api/v1/posts [post]
 func (a *api) CreatePost(ctx *gin.Context) {
 	var post *model.Post
 
 	if err := ctx.ShouldBindJSON(&post); err != nil {
-		serverErrorResponse(ctx.Writer, ctx.Request, http.StatusBadRequest, map[string]interface{}{
-			"data": map[string]interface{}{
-				"success": false,
-				"message": "missing post form data",
-				"error":   err.Error(),
-			},
-		}, err)
+		problemResponse(ctx, badRequest(err))
 		return
 	}
 
-	err := a.app.CreatePost(ctx, post)
+	err := a.app.CreatePost(appContext(ctx), post)
 	if err != nil {
-		serverErrorResponse(ctx.Writer, ctx.Request, http.StatusBadRequest, map[string]interface{}{
-			"data": map[string]interface{}{
-				"success": false,
-				"message": "Create new post failed",
-				"error":   err.Error(),
-			},
-		}, err)
+		problemResponse(ctx, err)
 		return
 	}
 
@@ -545,13 +452,7 @@ func (a *api) CreatePost(ctx *gin.Context) {
 		},
 	}, nil)
 	if err != nil {
-		serverErrorResponse(ctx.Writer, ctx.Request, http.StatusInternalServerError, map[string]interface{}{
-			"data": map[string]interface{}{
-				"success": false,
-				"message": "Create new post failed",
-				"error":   err.Error(),
-			},
-		}, err)
+		problemResponse(ctx, err)
 		return
 	}
 }
@@ -563,31 +464,19 @@ func (a *api) CreatePost(ctx *gin.Context) {
 // @Produce json
 // @Param id path int true "Post ID"
 // @Success 200 {object} map[string]interface{} "Post data"
-// @Failure 400 {object} map[string]interface{} "Bad request error with message"
-// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Failure 400 {object} problem "Bad request error with message"
+// @Failure 500 {object} problem "Internal server error"
 // @Router /api/v1/posts/{id} [get]
 func (a *api) GetPostByID(ctx *gin.Context) {
-	postID, err := GetParamByName(ctx, "id")
+	postID, err := getIntParam(ctx, "id")
 	if err != nil {
-		serverErrorResponse(ctx.Writer, ctx.Request, http.StatusBadRequest, map[string]interface{}{
-			"data": map[string]interface{}{
-				"success": false,
-				"message": "Get post by id failed",
-				"error":   fmt.Errorf("user id param is invalid").Error(),
-			},
-		}, fmt.Errorf("user id param is invalid"))
+		problemResponse(ctx, app.Invalid("id", "post id param is invalid"))
 		return
 	}
 
-	post, err := a.app.GetPostByID(ctx, postID.(int))
+	post, err := a.app.GetPostByID(appContext(ctx), postID)
 	if err != nil {
-		serverErrorResponse(ctx.Writer, ctx.Request, http.StatusBadRequest, map[string]interface{}{
-			"data": map[string]interface{}{
-				"success": false,
-				"message": "Get post by id failed",
-				"error":   err.Error(),
-			},
-		}, err)
+		problemResponse(ctx, err)
 		return
 	}
 
@@ -599,13 +488,50 @@ func (a *api) GetPostByID(ctx *gin.Context) {
 		},
 	}, nil)
 	if err != nil {
-		serverErrorResponse(ctx.Writer, ctx.Request, http.StatusInternalServerError, map[string]interface{}{
-			"data": map[string]interface{}{
-				"success": false,
-				"message": "Get post by id failed",
-				"error":   err.Error(),
-			},
-		}, err)
+		problemResponse(ctx, err)
+		return
+	}
+}
+
+// GetPostBySlug godoc
+// @Summary Get post by slug
+// @Description Retrieve a post by its slug. Old slugs of renamed posts redirect to the current slug
+// @Tags posts
+// @Produce json
+// @Param slug path string true "Post slug"
+// @Success 200 {object} map[string]interface{} "Post data"
+// @Success 301 {string} string "Redirect to the current slug of the post"
+// @Failure 400 {object} problem "Bad request error with message"
+// @Failure 500 {object} problem "Internal server error"
+// @Router /api/v1/posts/by-slug/{slug} [get]
+func (a *api) GetPostBySlug(ctx *gin.Context) {
+	slug, err := GetParamByName(ctx, "slug")
+	if err != nil {
+		problemResponse(ctx, app.Invalid("slug", "slug param is invalid"))
+		return
+	}
+
+	// numeric slugs come back from GetParamByName as ints
+	post, currentSlug, err := a.app.GetPostBySlug(appContext(ctx), fmt.Sprint(slug))
+	if err != nil {
+		problemResponse(ctx, err)
+		return
+	}
+
+	if currentSlug != "" {
+		ctx.Redirect(http.StatusMovedPermanently, "/api/v1/posts/by-slug/"+currentSlug)
+		return
+	}
+
+	err = writeJSON(ctx.Writer, http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{
+			"success": true,
+			"message": "Get post by slug successful",
+			"post":    post,
+		},
+	}, nil)
+	if err != nil {
+		problemResponse(ctx, err)
 		return
 	}
 }
@@ -617,31 +543,20 @@ func (a *api) GetPostByID(ctx *gin.Context) {
 // @Produce json
 // @Param id path int true "Post ID"
 // @Success 200 {object} map[string]interface{} "Post deletion successful"
-// @Failure 400 {object} map[string]interface{} "Bad request error with message"
-// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Failure 400 {object} problem "Bad request error with message"
+// @Failure 403 {object} problem "Post belongs to another author"
+// @Failure 500 {object} problem "Internal server error"
 // @Router /api/v1/posts/{id} [delete]
 func (a *api) DeletePostByID(ctx *gin.Context) {
-	postID, err := GetParamByName(ctx, "id")
+	postID, err := getIntParam(ctx, "id")
 	if err != nil {
-		serverErrorResponse(ctx.Writer, ctx.Request, http.StatusBadRequest, map[string]interface{}{
-			"data": map[string]interface{}{
-				"success": false,
-				"message": "Delete post failed",
-				"error":   fmt.Errorf("post id param is invalid").Error(),
-			},
-		}, fmt.Errorf("post id param is invalid"))
+		problemResponse(ctx, app.Invalid("id", "post id param is invalid"))
 		return
 	}
 
-	err = a.app.DeletePostByID(ctx, postID.(int))
+	err = a.app.DeletePostByID(appContext(ctx), postID)
 	if err != nil {
-		serverErrorResponse(ctx.Writer, ctx.Request, http.StatusBadRequest, map[string]interface{}{
-			"data": map[string]interface{}{
-				"success": false,
-				"message": "Delete post failed",
-				"error":   err.Error(),
-			},
-		}, err)
+		problemResponse(ctx, err)
 		return
 	}
 
@@ -652,50 +567,40 @@ func (a *api) DeletePostByID(ctx *gin.Context) {
 		},
 	}, nil)
 	if err != nil {
-		serverErrorResponse(ctx.Writer, ctx.Request, http.StatusInternalServerError, map[string]interface{}{
-			"data": map[string]interface{}{
-				"success": false,
-				"message": "Delete post failed",
-				"error":   err.Error(),
-			},
-		}, err)
+		problemResponse(ctx, err)
 		return
 	}
 }
 
 // UpdatePostByID godoc
 // @Summary Update a post by ID
-// @Description Update the details of an existing post by its unique ID
+// @Description Update the details of an existing post by its unique ID. Changing the slug keeps the old one as a redirect
 // @Tags posts
 // @Produce json
 // @Param id path int true "Post ID"
-// @Param post body model.Post true "Updated post data"
+// @Param post body model.UpdatePostInput true "Updated post data"
 // @Success 200 {object} map[string]interface{} "Post update successful"
-// @Failure 400 {object} map[string]interface{} "Bad request error with message"
-// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Failure 400 {object} problem "Bad request error with message"
+// @Failure 403 {object} problem "Post belongs to another author"
+// @Failure 409 {object} problem "Slug is already taken"
+// @Failure 500 {object} problem "Internal server error"
 // @Router /api/v1/posts/{id} [put]
 func (a *api) UpdatePostByID(ctx *gin.Context) {
-	postID, err := GetParamByName(ctx, "id")
+	postID, err := getIntParam(ctx, "id")
 	if err != nil {
-		serverErrorResponse(ctx.Writer, ctx.Request, http.StatusBadRequest, map[string]interface{}{
-			"data": map[string]interface{}{
-				"success": false,
-				"message": "Update post failed",
-				"error":   fmt.Errorf("post id param is invalid").Error(),
-			},
-		}, fmt.Errorf("post id param is invalid"))
+		problemResponse(ctx, app.Invalid("id", "post id param is invalid"))
 		return
 	}
 
-	err = a.app.UpdatePostByID(ctx, postID.(int))
+	var input model.UpdatePostInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		problemResponse(ctx, badRequest(err))
+		return
+	}
+
+	err = a.app.UpdatePostByID(appContext(ctx), postID, input)
 	if err != nil {
-		serverErrorResponse(ctx.Writer, ctx.Request, http.StatusBadRequest, map[string]interface{}{
-			"data": map[string]interface{}{
-				"success": false,
-				"message": "Update post failed",
-				"error":   err.Error(),
-			},
-		}, err)
+		problemResponse(ctx, err)
 		return
 	}
 
@@ -706,37 +611,76 @@ func (a *api) UpdatePostByID(ctx *gin.Context) {
 		},
 	}, nil)
 	if err != nil {
-		serverErrorResponse(ctx.Writer, ctx.Request, http.StatusInternalServerError, map[string]interface{}{
-			"data": map[string]interface{}{
-				"success": false,
-				"message": "Update post failed",
-				"error":   err.Error(),
-			},
-		}, err)
+		problemResponse(ctx, err)
+		return
+	}
+}
+
+// TransferPostByID godoc
+// @Summary Transfer a post to another author
+// @Description Change the author of a post. Only admins can transfer posts
+// @Tags posts
+// @Accept json
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param transferPostInput body model.TransferPostInput true "New author"
+// @Success 200 {object} map[string]interface{} "Post transfer successful"
+// @Failure 400 {object} problem "Bad request error with message"
+// @Failure 403 {object} problem "Permission denied"
+// @Failure 500 {object} problem "Internal server error"
+// @Router /api/v1/posts/{id}/transfer [post]
+func (a *api) TransferPostByID(ctx *gin.Context) {
+	postID, err := getIntParam(ctx, "id")
+	if err != nil {
+		problemResponse(ctx, app.Invalid("id", "post id param is invalid"))
+		return
+	}
+
+	var transferPostInput model.TransferPostInput
+	if err := ctx.ShouldBindJSON(&transferPostInput); err != nil {
+		problemResponse(ctx, badRequest(err))
+		return
+	}
+
+	err = a.app.TransferPostByID(appContext(ctx), postID, transferPostInput.UserID)
+	if err != nil {
+		problemResponse(ctx, err)
+		return
+	}
+
+	err = writeJSON(ctx.Writer, http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{
+			"success": true,
+			"message": "Transfer post successful",
+		},
+	}, nil)
+	if err != nil {
+		problemResponse(ctx, err)
 		return
 	}
 }
 
 // LikePostByID godoc
 // @Summary Like a post by ID
-// @Description Like a specific post by its unique ID
+// @Description Like a specific post by its unique ID as the authenticated user
 // @Tags posts
 // @Produce json
 // @Param id path int true "Post ID"
 // @Success 200 {object} map[string]interface{} "Like post successful"
-// @Failure 400 {object} map[string]interface{} "Bad request error with message"
-// @Failure 500 {object} map[string]interface{} "Internal server error"
-// @Router /api/v1/posts/{id}/like [put]
+// @Failure 400 {object} problem "Bad request error with message"
+// @Failure 409 {object} problem "Post is already liked"
+// @Failure 500 {object} problem "Internal server error"
+// @Router /api/v1/posts/{id}/like [post]
 func (a *api) LikePostByID(ctx *gin.Context) {
-	err := a.app.LikePostByID(ctx)
+	targetID, err := getTargetID(ctx, "post_id")
 	if err != nil {
-		serverErrorResponse(ctx.Writer, ctx.Request, http.StatusBadRequest, map[string]interface{}{
-			"data": map[string]interface{}{
-				"success": false,
-				"message": "Like post failed",
-				"error":   err.Error(),
-			},
-		}, err)
+		problemResponse(ctx, badRequest(err))
+		return
+	}
+
+	err = a.app.LikePostByID(appContext(ctx), targetID)
+	if err != nil {
+		problemResponse(ctx, err)
 		return
 	}
 
@@ -747,37 +691,31 @@ func (a *api) LikePostByID(ctx *gin.Context) {
 		},
 	}, nil)
 	if err != nil {
-		serverErrorResponse(ctx.Writer, ctx.Request, http.StatusInternalServerError, map[string]interface{}{
-			"data": map[string]interface{}{
-				"success": false,
-				"message": "Like post failed",
-				"error":   err.Error(),
-			},
-		}, err)
+		problemResponse(ctx, err)
 		return
 	}
 }
 
 // UnLikePostByID godoc
 // @Summary Unlike a post by ID
-// @Description Unlike a specific post by its unique ID
+// @Description Unlike a specific post by its unique ID as the authenticated user
 // @Tags posts
 // @Produce json
 // @Param id path int true "Post ID"
 // @Success 200 {object} map[string]interface{} "Unlike post successful"
-// @Failure 400 {object} map[string]interface{} "Bad request error with message"
-// @Failure 500 {object} map[string]interface{} "Internal server error"
-// @Router /api/v1/posts/{id}/unlike [put]
+// @Failure 400 {object} problem "Bad request error with message"
+// @Failure 500 {object} problem "Internal server error"
+// @Router /api/v1/posts/{id}/like [delete]
 func (a *api) UnLikePostByID(ctx *gin.Context) {
-	err := a.app.UnlikePostByID(ctx)
+	targetID, err := getTargetID(ctx, "post_id")
 	if err != nil {
-		serverErrorResponse(ctx.Writer, ctx.Request, http.StatusBadRequest, map[string]interface{}{
-			"data": map[string]interface{}{
-				"success": false,
-				"message": "Unlike post failed",
-				"error":   err.Error(),
-			},
-		}, err)
+		problemResponse(ctx, badRequest(err))
+		return
+	}
+
+	err = a.app.UnlikePostByID(appContext(ctx), targetID)
+	if err != nil {
+		problemResponse(ctx, err)
 		return
 	}
 
@@ -788,13 +726,7 @@ func (a *api) UnLikePostByID(ctx *gin.Context) {
 		},
 	}, nil)
 	if err != nil {
-		serverErrorResponse(ctx.Writer, ctx.Request, http.StatusInternalServerError, map[string]interface{}{
-			"data": map[string]interface{}{
-				"success": false,
-				"message": "Unlike post failed",
-				"error":   err.Error(),
-			},
-		}, err)
+		problemResponse(ctx, err)
 		return
 	}
 }
@@ -806,63 +738,50 @@ func (a *api) UnLikePostByID(ctx *gin.Context) {
 // @Produce json
 // @Param loginInput body model.LoginInput true "Login credentials"
 // @Success 200 {object} map[string]interface{} "Login successful with token and user data"
-// @Failure 400 {object} map[string]interface{} "Bad request error with message"
-// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Failure 400 {object} problem "Bad request error with message"
+// @Failure 500 {object} problem "Internal server error"
 // @Router /api/v1/auth/login [post]
 func (a *api) Login(ctx *gin.Context) {
 	var loginInput model.LoginInput
 
 	if err := ctx.ShouldBindJSON(&loginInput); err != nil {
-		serverErrorResponse(ctx.Writer, ctx.Request, http.StatusBadRequest, map[string]interface{}{
-			"data": map[string]interface{}{
-				"success": false,
-				"message": "Login failed",
-				"error":   err.Error(),
-			},
-		}, err)
+		problemResponse(ctx, badRequest(err))
 		return
 	}
 
-	user, err := a.app.Login(ctx, loginInput)
+	user, err := a.app.Login(appContext(ctx), loginInput)
 	if err != nil {
-		serverErrorResponse(ctx.Writer, ctx.Request, http.StatusBadRequest, map[string]interface{}{
-			"data": map[string]interface{}{
-				"success": false,
-				"message": "Login failed",
-				"error":   err.Error(),
-			},
-		}, err)
+		problemResponse(ctx, err)
 		return
 	}
 
-	token, err := createJwtToken(user.Username)
+	session, refreshToken, err := a.app.CreateSession(appContext(ctx), model.SessionInput{
+		UserID:    user.ID,
+		UserAgent: ctx.Request.UserAgent(),
+		IP:        ctx.ClientIP(),
+	})
 	if err != nil {
-		serverErrorResponse(ctx.Writer, ctx.Request, http.StatusInternalServerError, map[string]interface{}{
-			"data": map[string]interface{}{
-				"success": false,
-				"message": "Login failed",
-				"error":   err.Error(),
-			},
-		}, err)
+		problemResponse(ctx, err)
+		return
+	}
+
+	token, err := createJwtToken(user.Username, session.ID)
+	if err != nil {
+		problemResponse(ctx, err)
 		return
 	}
 
 	err = writeJSON(ctx.Writer, http.StatusOK, map[string]interface{}{
 		"data": map[string]interface{}{
-			"success": true,
-			"message": "Login successful",
-			"token":   token,
-			"user":    user,
+			"success":       true,
+			"message":       "Login successful",
+			"token":         token,
+			"refresh_token": refreshToken,
+			"user":          user,
 		},
 	}, nil)
 	if err != nil {
-		serverErrorResponse(ctx.Writer, ctx.Request, http.StatusInternalServerError, map[string]interface{}{
-			"data": map[string]interface{}{
-				"success": false,
-				"message": "Login failed",
-				"error":   err.Error(),
-			},
-		}, err)
+		problemResponse(ctx, err)
 		return
 	}
 }
@@ -874,32 +793,20 @@ func (a *api) Login(ctx *gin.Context) {
 // @Produce json
 // @Param registerInput body model.RegisterInput true "Register credentials"
 // @Success 200 {object} map[string]interface{} "Register successful with user data"
-// @Failure 400 {object} map[string]interface{} "Bad request error with message"
-// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Failure 400 {object} problem "Bad request error with message"
+// @Failure 500 {object} problem "Internal server error"
 // @Router /api/v1/auth/register [post]
 func (a *api) Register(ctx *gin.Context) {
 	var registerInput model.RegisterInput
 
 	if err := ctx.ShouldBindJSON(&registerInput); err != nil {
-		serverErrorResponse(ctx.Writer, ctx.Request, http.StatusBadRequest, map[string]interface{}{
-			"data": map[string]interface{}{
-				"success": false,
-				"message": "Register failed",
-				"error":   err.Error(),
-			},
-		}, err)
+		problemResponse(ctx, badRequest(err))
 		return
 	}
 
-	user, err := a.app.Register(ctx, registerInput)
+	user, err := a.app.Register(appContext(ctx), registerInput)
 	if err != nil {
-		serverErrorResponse(ctx.Writer, ctx.Request, http.StatusBadRequest, map[string]interface{}{
-			"data": map[string]interface{}{
-				"success": false,
-				"message": "Register failed",
-				"error":   err.Error(),
-			},
-		}, err)
+		problemResponse(ctx, err)
 		return
 	}
 
@@ -911,13 +818,106 @@ func (a *api) Register(ctx *gin.Context) {
 		},
 	}, nil)
 	if err != nil {
-		serverErrorResponse(ctx.Writer, ctx.Request, http.StatusInternalServerError, map[string]interface{}{
-			"data": map[string]interface{}{
-				"success": false,
-				"message": "Register failed",
-				"error":   err.Error(),
-			},
-		}, err)
+		problemResponse(ctx, err)
+		return
+	}
+}
+
+// Refresh godoc
+// @Summary Refresh an access token
+// @Description Exchanges a refresh token for a new access token and rotates the refresh token
+// @Tags auth
+// @Produce json
+// @Param refreshInput body model.RefreshInput true "Refresh token"
+// @Success 200 {object} map[string]interface{} "Refresh successful with new tokens"
+// @Failure 400 {object} problem "Bad request error with message"
+// @Failure 401 {object} problem "Refresh token is invalid, expired or revoked"
+// @Failure 500 {object} problem "Internal server error"
+// @Router /api/v1/auth/refresh [post]
+func (a *api) Refresh(ctx *gin.Context) {
+	var refreshInput model.RefreshInput
+
+	if err := ctx.ShouldBindJSON(&refreshInput); err != nil {
+		problemResponse(ctx, badRequest(err))
+		return
+	}
+
+	session, refreshToken, err := a.app.RefreshSession(appContext(ctx), refreshInput.RefreshToken)
+	if err != nil {
+		problemResponse(ctx, err)
+		return
+	}
+
+	token, err := createJwtToken(session.User.Username, session.ID)
+	if err != nil {
+		problemResponse(ctx, err)
+		return
+	}
+
+	err = writeJSON(ctx.Writer, http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{
+			"success":       true,
+			"message":       "Refresh successful",
+			"token":         token,
+			"refresh_token": refreshToken,
+		},
+	}, nil)
+	if err != nil {
+		problemResponse(ctx, err)
+		return
+	}
+}
+
+// Logout godoc
+// @Summary Logout the current session
+// @Description Revokes the session of the given access token and its refresh token
+// @Tags auth
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Logout successful"
+// @Failure 500 {object} problem "Internal server error"
+// @Router /api/v1/auth/logout [post]
+func (a *api) Logout(ctx *gin.Context) {
+	err := a.app.Logout(appContext(ctx), ctx.GetUint("session_id"))
+	if err != nil {
+		problemResponse(ctx, err)
+		return
+	}
+
+	err = writeJSON(ctx.Writer, http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{
+			"success": true,
+			"message": "Logout successful",
+		},
+	}, nil)
+	if err != nil {
+		problemResponse(ctx, err)
+		return
+	}
+}
+
+// LogoutAll godoc
+// @Summary Logout from all devices
+// @Description Revokes every session of the current user
+// @Tags auth
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Logout from all devices successful"
+// @Failure 500 {object} problem "Internal server error"
+// @Router /api/v1/auth/logout/all [post]
+func (a *api) LogoutAll(ctx *gin.Context) {
+	err := a.app.LogoutAll(appContext(ctx), ctx.GetUint("session_id"))
+	if err != nil {
+		problemResponse(ctx, err)
+		return
+	}
+
+	err = writeJSON(ctx.Writer, http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{
+			"success": true,
+			"message": "Logout from all devices successful",
+		},
+	}, nil)
+	if err != nil {
+		problemResponse(ctx, err)
 		return
 	}
 }
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pooulad/blogo/internal/app"
+)
+
+// readyTimeout bounds the database ping of the readiness probe.
+const readyTimeout = 2 * time.Second
+
+// Healthz godoc
+// @Summary Liveness probe
+// @Description Report that the process is up. it does not check any dependency
+// @Tags health
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Process is up"
+// @Router /healthz [get]
+func (a *api) Healthz(ctx *gin.Context) {
+	err := writeJSON(ctx.Writer, http.StatusOK, map[string]interface{}{
+		"status": "ok",
+	}, nil)
+	if err != nil {
+		problemResponse(ctx, err)
+	}
+}
+
+// Readyz godoc
+// @Summary Readiness probe
+// @Description Report whether the server can take traffic: the database answers a ping and migrations are done. it fails as soon as shutdown starts
+// @Tags health
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Ready to serve requests"
+// @Failure 503 {object} problem "Not ready with the reason"
+// @Router /readyz [get]
+func (a *api) Readyz(ctx *gin.Context) {
+	if a.shuttingDown.Load() {
+		problemResponse(ctx, app.Unavailable("shutting_down", "server is shutting down", nil))
+		return
+	}
+
+	pingCtx, cancel := context.WithTimeout(ctx.Request.Context(), readyTimeout)
+	defer cancel()
+
+	if err := a.app.Ready(pingCtx); err != nil {
+		problemResponse(ctx, err)
+		return
+	}
+
+	err := writeJSON(ctx.Writer, http.StatusOK, map[string]interface{}{
+		"status": "ok",
+	}, nil)
+	if err != nil {
+		problemResponse(ctx, err)
+	}
+}
@@ -1,69 +1,81 @@
 package api
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/joho/godotenv"
+	"github.com/pooulad/blogo/internal/app"
+	"github.com/pooulad/blogo/internal/database/model"
+	"github.com/pooulad/blogo/internal/logging"
+	"github.com/pooulad/blogo/internal/metrics"
 )
 
-func jwtMiddleware() gin.HandlerFunc {
+const (
+	accessTokenTTL  = time.Minute * 15
+	requestIDHeader = "X-Request-ID"
+)
+
+func (a *api) jwtMiddleware() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		jwtToken, err := extractBearerToken(ctx.GetHeader("Authorization"))
 		if err != nil {
-			serverErrorResponse(ctx.Writer, ctx.Request, http.StatusBadRequest, map[string]interface{}{
-				"data": map[string]interface{}{
-					"success": false,
-					"message": "token receive failed",
-					"error":   err.Error(),
-				},
-			}, err)
+			problemResponse(ctx, app.Unauthorized("missing_token", err.Error()))
 			ctx.Abort()
 			return
 		}
 
-		fmt.Println(jwtToken)
-
 		token, err := verifyJwtToken(jwtToken)
 		if err != nil {
-			serverErrorResponse(ctx.Writer, ctx.Request, http.StatusInternalServerError, map[string]interface{}{
-				"data": map[string]interface{}{
-					"success": false,
-					"message": "verify token failed",
-					"error":   err.Error(),
-				},
-			}, err)
+			problemResponse(ctx, err)
 			ctx.Abort()
 			return
 		}
 
 		claims, OK := token.Claims.(jwt.MapClaims)
 		if !OK {
-			serverErrorResponse(ctx.Writer, ctx.Request, http.StatusInternalServerError, map[string]interface{}{
-				"data": map[string]interface{}{
-					"success": false,
-					"message": "Operation failed",
-					"error":   fmt.Errorf("authentication error happend").Error(),
-				},
-			}, fmt.Errorf("authentication error happend"))
+			problemResponse(ctx, app.Unauthorized("invalid_token", "token is invalid"))
 			ctx.Abort()
 			return
 		}
 
 		username, OK := claims["username"].(string)
 		if !OK {
-			serverErrorResponse(ctx.Writer, ctx.Request, http.StatusInternalServerError, map[string]interface{}{
-				"data": map[string]interface{}{
-					"success": false,
-					"message": "Operation failed",
-					"error":   fmt.Errorf("authentication error happend").Error(),
-				},
-			}, fmt.Errorf("authentication error happend"))
+			problemResponse(ctx, app.Unauthorized("invalid_token", "token is invalid"))
+			ctx.Abort()
+			return
+		}
+
+		sessionID, OK := claims["sid"].(float64)
+		if !OK {
+			problemResponse(ctx, app.Unauthorized("invalid_token", "token is not bound to a session"))
+			ctx.Abort()
+			return
+		}
+
+		active, err := a.app.IsSessionActive(appContext(ctx), uint(sessionID))
+		if err != nil || !active {
+			problemResponse(ctx, app.Unauthorized("session_revoked", "session is expired or revoked"))
+			ctx.Abort()
+			return
+		}
+
+		user, err := a.app.GetUserByUsername(appContext(ctx), username)
+		if errors.Is(err, model.ErrNotFound) {
+			err = app.Unauthorized("invalid_token", "user of the token doesn't exist")
+		}
+		if err != nil {
+			problemResponse(ctx, err)
 			ctx.Abort()
 			return
 		}
@@ -75,12 +87,169 @@ func jwtMiddleware() gin.HandlerFunc {
 		// }
 		// ---------------------------------------------------------------------------------
 		ctx.Set("username", username)
+		ctx.Set("session_id", uint(sessionID))
+		ctx.Set("user_id", user.ID)
+		ctx.Set("role", user.Role)
+
+		ctx.Next()
+	}
+}
+
+// requestID tags the request with the id sent by the client in X-Request-ID,
+// or a new one, and echoes it in the response. every log record of the
+// request carries the id.
+func requestID() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.GetHeader(requestIDHeader)
+		if !isValidRequestID(id) {
+			id = newRequestID()
+		}
+
+		ctx.Set("request_id", id)
+		ctx.Header(requestIDHeader, id)
+		ctx.Request = ctx.Request.WithContext(logging.WithRequestID(ctx.Request.Context(), id))
+
+		ctx.Next()
+	}
+}
+
+// isValidRequestID accepts short ids made of safe characters only, so
+// clients can't inject anything into the logs through the header.
+func isValidRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_.:", r)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+
+	return hex.EncodeToString(b)
+}
+
+// accessLog logs every request once it is served. the query string isn't
+// logged since it may carry secrets.
+func accessLog() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+
+		ctx.Next()
+
+		status := ctx.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", ctx.Request.Method),
+			slog.String("path", ctx.Request.URL.Path),
+			slog.String("route", ctx.FullPath()),
+			slog.Int("status", status),
+			slog.Int("bytes", max(ctx.Writer.Size(), 0)),
+			slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("client_ip", ctx.ClientIP()),
+			slog.String("user_agent", ctx.Request.UserAgent()),
+		}
+		if userID := ctx.GetUint("user_id"); userID != 0 {
+			attrs = append(attrs, slog.Uint64("user_id", uint64(userID)))
+		}
+
+		slog.LogAttrs(ctx.Request.Context(), level, "request", attrs...)
+	}
+}
+
+// instrument records the count, latency and concurrency of requests. routes
+// are labelled by their template, so /posts/get/1 and /posts/get/2 share a
+// series.
+func instrument() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		metrics.HTTPRequestsInFlight.Inc()
+		defer metrics.HTTPRequestsInFlight.Dec()
+
+		ctx.Next()
+
+		route := ctx.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		metrics.HTTPRequests.WithLabelValues(ctx.Request.Method, route, strconv.Itoa(ctx.Writer.Status())).Inc()
+		metrics.HTTPRequestDuration.WithLabelValues(ctx.Request.Method, route).Observe(time.Since(start).Seconds())
+	}
+}
+
+// optionalJwtMiddleware authenticates requests with a token like
+// jwtMiddleware and lets requests without one through anonymously. a bad
+// token is still rejected, so clients notice it instead of silently losing
+// their personalised fields.
+func (a *api) optionalJwtMiddleware() gin.HandlerFunc {
+	authenticate := a.jwtMiddleware()
+
+	return func(ctx *gin.Context) {
+		if ctx.GetHeader("Authorization") == "" {
+			ctx.Next()
+			return
+		}
+
+		authenticate(ctx)
+	}
+}
+
+// authorize aborts the request unless the role set by jwtMiddleware is
+// granted the permission. anonymous requests let through by
+// optionalJwtMiddleware only get the anonymous permissions.
+func (a *api) authorize(permission app.Permission) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if _, authenticated := ctx.Get("user_id"); !authenticated {
+			if !app.HasAnonymousPermission(permission) {
+				problemResponse(ctx, app.Unauthorized("authentication_required", "authentication required"))
+				ctx.Abort()
+				return
+			}
+
+			ctx.Next()
+			return
+		}
+
+		if !app.HasPermission(ctx.GetString("role"), permission) {
+			problemResponse(ctx, app.ErrPermissionDenied)
+			ctx.Abort()
+			return
+		}
 
 		ctx.Next()
 	}
 }
 
-func createJwtToken(username string) (string, error) {
+// deprecated marks responses of a deprecated route and points clients to
+// the route replacing it.
+func deprecated(successor string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Header("Deprecation", "true")
+		ctx.Header("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", successor))
+		ctx.Next()
+	}
+}
+
+// createJwtToken issues a short lived access token bound to a session.
+// clients use the refresh token of the session to get a new one.
+func createJwtToken(username string, sessionID uint) (string, error) {
 	secretKey, err := getSecretKey()
 	if err != nil {
 		return "", err
@@ -88,7 +257,8 @@ func createJwtToken(username string) (string, error) {
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 		"username": username,
-		"exp":      time.Now().Add(time.Minute * 200).Unix(),
+		"sid":      sessionID,
+		"exp":      time.Now().Add(accessTokenTTL).Unix(),
 	})
 
 	tokenString, err := token.SignedString([]byte(secretKey))
@@ -109,12 +279,9 @@ func verifyJwtToken(tokenString string) (*jwt.Token, error) {
 		return []byte(secretKey), nil
 	})
 
-	if err != nil {
-		return nil, err
-	}
-
-	if !token.Valid {
-		return nil, fmt.Errorf("invalid token")
+	// the secret key is fine, so the token itself is expired or forged
+	if err != nil || !token.Valid {
+		return nil, app.Unauthorized("invalid_token", "token is invalid")
 	}
 
 	return token, nil
@@ -0,0 +1,145 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pooulad/blogo/internal/app"
+	"github.com/pooulad/blogo/internal/database/model"
+)
+
+// GetPostRevisions godoc
+// @Summary Get the revisions of a post
+// @Description Retrieve the revisions of a post. A revision is written every time the post is created or updated
+// @Tags revisions
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param limit query int false "Page size, 20 by default and 100 at most"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param sort query string false "newest (default) or oldest"
+// @Success 200 {object} map[string]interface{} "Post revisions"
+// @Failure 400 {object} problem "Bad request error with message"
+// @Failure 403 {object} problem "Post belongs to another author"
+// @Failure 500 {object} problem "Internal server error"
+// @Router /api/v1/posts/{id}/revisions [get]
+func (a *api) GetPostRevisions(ctx *gin.Context) {
+	postID, err := getIntParam(ctx, "id")
+	if err != nil {
+		problemResponse(ctx, app.Invalid("id", "post id param is invalid"))
+		return
+	}
+
+	var query model.PageQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		problemResponse(ctx, badRequest(err))
+		return
+	}
+
+	revisions, page, err := a.app.GetPostRevisions(appContext(ctx), postID, query)
+	if err != nil {
+		problemResponse(ctx, err)
+		return
+	}
+
+	err = writeJSON(ctx.Writer, http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{
+			"success":     true,
+			"message":     "Get post revisions successful",
+			"revisions":   revisions,
+			"next_cursor": page.NextCursor,
+			"has_more":    page.HasMore,
+		},
+	}, nil)
+	if err != nil {
+		problemResponse(ctx, err)
+		return
+	}
+}
+
+// DiffPostRevisions godoc
+// @Summary Diff two revisions of a post
+// @Description Get a unified diff of the title and content between two revisions of a post
+// @Tags revisions
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param from query int true "Revision number to diff from"
+// @Param to query int true "Revision number to diff to"
+// @Success 200 {object} map[string]interface{} "Unified diff"
+// @Failure 400 {object} problem "Bad request error with message"
+// @Failure 403 {object} problem "Post belongs to another author"
+// @Failure 500 {object} problem "Internal server error"
+// @Router /api/v1/posts/{id}/revisions/diff [get]
+func (a *api) DiffPostRevisions(ctx *gin.Context) {
+	postID, err := getIntParam(ctx, "id")
+	if err != nil {
+		problemResponse(ctx, app.Invalid("id", "post id param is invalid"))
+		return
+	}
+
+	var query model.RevisionDiffQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		problemResponse(ctx, badRequest(err))
+		return
+	}
+
+	diff, err := a.app.DiffPostRevisions(appContext(ctx), postID, query)
+	if err != nil {
+		problemResponse(ctx, err)
+		return
+	}
+
+	err = writeJSON(ctx.Writer, http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{
+			"success": true,
+			"message": "Diff post revisions successful",
+			"diff":    diff,
+		},
+	}, nil)
+	if err != nil {
+		problemResponse(ctx, err)
+		return
+	}
+}
+
+// RestorePostRevision godoc
+// @Summary Restore a revision of a post
+// @Description Copy the title and content of an old revision back into the post. The restored content is saved as a new revision
+// @Tags revisions
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param number path int true "Revision number"
+// @Success 200 {object} map[string]interface{} "Restore post revision successful"
+// @Failure 400 {object} problem "Bad request error with message"
+// @Failure 403 {object} problem "Post belongs to another author"
+// @Failure 500 {object} problem "Internal server error"
+// @Router /api/v1/posts/{id}/revisions/{number}/restore [post]
+func (a *api) RestorePostRevision(ctx *gin.Context) {
+	postID, err := getIntParam(ctx, "id")
+	if err != nil {
+		problemResponse(ctx, app.Invalid("id", "post id param is invalid"))
+		return
+	}
+
+	number, err := getIntParam(ctx, "number")
+	if err != nil {
+		problemResponse(ctx, app.Invalid("number", "revision number param is invalid"))
+		return
+	}
+
+	err = a.app.RestorePostRevision(appContext(ctx), postID, number)
+	if err != nil {
+		problemResponse(ctx, err)
+		return
+	}
+
+	err = writeJSON(ctx.Writer, http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{
+			"success": true,
+			"message": "Restore post revision successful",
+		},
+	}, nil)
+	if err != nil {
+		problemResponse(ctx, err)
+		return
+	}
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pooulad/blogo/internal/app"
+	"github.com/pooulad/blogo/internal/database/model"
+)
+
+// Search godoc
+// @Summary Search posts or users
+// @Description Full-text search over post titles and contents, or over user names and skills. Every word matches as a prefix and results are ordered by rank. Headline and snippet are HTML-escaped plain text in which matches are wrapped in <mark> tags
+// @Tags search
+// @Produce json
+// @Param q query string true "Search text"
+// @Param type query string false "posts (default) or users"
+// @Param limit query int false "Page size, 20 by default and 100 at most"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Success 200 {object} map[string]interface{} "Search results"
+// @Failure 400 {object} problem "Bad request error with message"
+// @Failure 500 {object} problem "Internal server error"
+// @Router /api/v1/search [get]
+func (a *api) Search(ctx *gin.Context) {
+	var query model.SearchQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		problemResponse(ctx, badRequest(err))
+		return
+	}
+
+	var (
+		results interface{}
+		page    *model.Page
+		err     error
+	)
+	switch query.Type {
+	case "", model.SearchTypePosts:
+		query.Type = model.SearchTypePosts
+		results, page, err = a.app.SearchPosts(appContext(ctx), query)
+	case model.SearchTypeUsers:
+		results, page, err = a.app.SearchUsers(appContext(ctx), query)
+	default:
+		err = app.Invalid("type", fmt.Sprintf("type must be %s or %s", model.SearchTypePosts, model.SearchTypeUsers))
+	}
+	if err != nil {
+		problemResponse(ctx, err)
+		return
+	}
+
+	err = writeJSON(ctx.Writer, http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{
+			"success":     true,
+			"message":     "Search successful",
+			"type":        query.Type,
+			"results":     results,
+			"next_cursor": page.NextCursor,
+			"has_more":    page.HasMore,
+		},
+	}, nil)
+	if err != nil {
+		problemResponse(ctx, err)
+		return
+	}
+}
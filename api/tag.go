@@ -0,0 +1,349 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pooulad/blogo/internal/app"
+	"github.com/pooulad/blogo/internal/database/model"
+)
+
+// AttachTagsToPost godoc
+// @Summary Attach tags to a post
+// @Description Attach tags to a post by name. Names are normalised to slugs and missing tags are created
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param tagsInput body model.TagsInput true "Tag names"
+// @Success 200 {object} map[string]interface{} "Attach tags successful"
+// @Failure 400 {object} problem "Bad request error with message"
+// @Failure 403 {object} problem "Post belongs to another author"
+// @Failure 500 {object} problem "Internal server error"
+// @Router /api/v1/posts/{id}/tags [post]
+func (a *api) AttachTagsToPost(ctx *gin.Context) {
+	postID, err := getIntParam(ctx, "id")
+	if err != nil {
+		problemResponse(ctx, app.Invalid("id", "post id param is invalid"))
+		return
+	}
+
+	var tagsInput model.TagsInput
+	if err := ctx.ShouldBindJSON(&tagsInput); err != nil {
+		problemResponse(ctx, badRequest(err))
+		return
+	}
+
+	err = a.app.AttachTagsToPost(appContext(ctx), postID, tagsInput.Tags)
+	if err != nil {
+		problemResponse(ctx, err)
+		return
+	}
+
+	err = writeJSON(ctx.Writer, http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{
+			"success": true,
+			"message": "Attach tags successful",
+		},
+	}, nil)
+	if err != nil {
+		problemResponse(ctx, err)
+		return
+	}
+}
+
+// DetachTagFromPost godoc
+// @Summary Detach a tag from a post
+// @Description Remove a tag from a post
+// @Tags tags
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param slug path string true "Tag slug"
+// @Success 200 {object} map[string]interface{} "Detach tag successful"
+// @Failure 400 {object} problem "Bad request error with message"
+// @Failure 403 {object} problem "Post belongs to another author"
+// @Failure 500 {object} problem "Internal server error"
+// @Router /api/v1/posts/{id}/tags/{slug} [delete]
+func (a *api) DetachTagFromPost(ctx *gin.Context) {
+	postID, err := getIntParam(ctx, "id")
+	if err != nil {
+		problemResponse(ctx, app.Invalid("id", "post id param is invalid"))
+		return
+	}
+
+	err = a.app.DetachTagFromPost(appContext(ctx), postID, ctx.Param("slug"))
+	if err != nil {
+		problemResponse(ctx, err)
+		return
+	}
+
+	err = writeJSON(ctx.Writer, http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{
+			"success": true,
+			"message": "Detach tag successful",
+		},
+	}, nil)
+	if err != nil {
+		problemResponse(ctx, err)
+		return
+	}
+}
+
+// GetTagCounts godoc
+// @Summary Get tag counts
+// @Description Retrieve every tag with its number of published posts, for a tag cloud
+// @Tags tags
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Tags with post counts"
+// @Failure 500 {object} problem "Internal server error"
+// @Router /api/v1/tags [get]
+func (a *api) GetTagCounts(ctx *gin.Context) {
+	tags, err := a.app.GetTagCounts(appContext(ctx))
+	if err != nil {
+		problemResponse(ctx, err)
+		return
+	}
+
+	err = writeJSON(ctx.Writer, http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{
+			"success": true,
+			"message": "Get tags successful",
+			"tags":    tags,
+		},
+	}, nil)
+	if err != nil {
+		problemResponse(ctx, err)
+		return
+	}
+}
+
+// GetPostsByTag godoc
+// @Summary Get posts by tag
+// @Description Retrieve the posts with the given tag. Slugs of merged tags resolve to the tag they were merged into
+// @Tags tags
+// @Produce json
+// @Param slug path string true "Tag slug"
+// @Param limit query int false "Page size, 20 by default and 100 at most"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param sort query string false "newest (default) or oldest"
+// @Success 200 {object} map[string]interface{} "Success response with a list of posts"
+// @Failure 400 {object} problem "Bad request error with message"
+// @Router /api/v1/tags/{slug}/posts [get]
+func (a *api) GetPostsByTag(ctx *gin.Context) {
+	var query model.PostQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		problemResponse(ctx, badRequest(err))
+		return
+	}
+
+	query.Tag = ctx.Param("slug")
+	a.writePosts(ctx, query)
+}
+
+// MergeTags godoc
+// @Summary Merge a tag into another
+// @Description Move every post of a tag to another tag and delete it. The old slug keeps resolving to the new tag. Only admins can merge tags
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Param slug path string true "Slug of the tag to merge"
+// @Param mergeTagsInput body model.MergeTagsInput true "Tag to merge into"
+// @Success 200 {object} map[string]interface{} "Merge tags successful"
+// @Failure 400 {object} problem "Bad request error with message"
+// @Failure 403 {object} problem "Permission denied"
+// @Failure 500 {object} problem "Internal server error"
+// @Router /api/v1/tags/{slug}/merge [post]
+func (a *api) MergeTags(ctx *gin.Context) {
+	var mergeTagsInput model.MergeTagsInput
+	if err := ctx.ShouldBindJSON(&mergeTagsInput); err != nil {
+		problemResponse(ctx, badRequest(err))
+		return
+	}
+
+	err := a.app.MergeTags(appContext(ctx), ctx.Param("slug"), mergeTagsInput.Into)
+	if err != nil {
+		problemResponse(ctx, err)
+		return
+	}
+
+	err = writeJSON(ctx.Writer, http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{
+			"success": true,
+			"message": "Merge tags successful",
+		},
+	}, nil)
+	if err != nil {
+		problemResponse(ctx, err)
+		return
+	}
+}
+
+// CreateCategory godoc
+// @Summary Create a category
+// @Description Create a post category. The slug is derived from the name when it is empty. Only editors and admins can create categories
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param category body model.Category true "Category data"
+// @Success 200 {object} map[string]interface{} "Create category successful"
+// @Failure 400 {object} problem "Bad request error with message"
+// @Failure 403 {object} problem "Permission denied"
+// @Failure 500 {object} problem "Internal server error"
+// @Router /api/v1/categories [post]
+func (a *api) CreateCategory(ctx *gin.Context) {
+	var category model.Category
+	if err := ctx.ShouldBindJSON(&category); err != nil {
+		problemResponse(ctx, badRequest(err))
+		return
+	}
+
+	err := a.app.CreateCategory(appContext(ctx), &category)
+	if err != nil {
+		problemResponse(ctx, err)
+		return
+	}
+
+	err = writeJSON(ctx.Writer, http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{
+			"success":  true,
+			"message":  "Create category successful",
+			"category": category,
+		},
+	}, nil)
+	if err != nil {
+		problemResponse(ctx, err)
+		return
+	}
+}
+
+// GetCategoryCounts godoc
+// @Summary Get categories
+// @Description Retrieve every category with its number of published posts
+// @Tags categories
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Categories with post counts"
+// @Failure 500 {object} problem "Internal server error"
+// @Router /api/v1/categories [get]
+func (a *api) GetCategoryCounts(ctx *gin.Context) {
+	categories, err := a.app.GetCategoryCounts(appContext(ctx))
+	if err != nil {
+		problemResponse(ctx, err)
+		return
+	}
+
+	err = writeJSON(ctx.Writer, http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{
+			"success":    true,
+			"message":    "Get categories successful",
+			"categories": categories,
+		},
+	}, nil)
+	if err != nil {
+		problemResponse(ctx, err)
+		return
+	}
+}
+
+// GetPostsByCategory godoc
+// @Summary Get posts by category
+// @Description Retrieve the posts in the given category
+// @Tags categories
+// @Produce json
+// @Param slug path string true "Category slug"
+// @Param limit query int false "Page size, 20 by default and 100 at most"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param sort query string false "newest (default) or oldest"
+// @Success 200 {object} map[string]interface{} "Success response with a list of posts"
+// @Failure 400 {object} problem "Bad request error with message"
+// @Router /api/v1/categories/{slug}/posts [get]
+func (a *api) GetPostsByCategory(ctx *gin.Context) {
+	var query model.PostQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		problemResponse(ctx, badRequest(err))
+		return
+	}
+
+	query.Category = ctx.Param("slug")
+	a.writePosts(ctx, query)
+}
+
+// AttachCategoriesToPost godoc
+// @Summary Attach categories to a post
+// @Description Attach existing categories to a post by slug
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param categoriesInput body model.CategoriesInput true "Category slugs"
+// @Success 200 {object} map[string]interface{} "Attach categories successful"
+// @Failure 400 {object} problem "Bad request error with message"
+// @Failure 403 {object} problem "Post belongs to another author"
+// @Failure 500 {object} problem "Internal server error"
+// @Router /api/v1/posts/{id}/categories [post]
+func (a *api) AttachCategoriesToPost(ctx *gin.Context) {
+	postID, err := getIntParam(ctx, "id")
+	if err != nil {
+		problemResponse(ctx, app.Invalid("id", "post id param is invalid"))
+		return
+	}
+
+	var categoriesInput model.CategoriesInput
+	if err := ctx.ShouldBindJSON(&categoriesInput); err != nil {
+		problemResponse(ctx, badRequest(err))
+		return
+	}
+
+	err = a.app.AttachCategoriesToPost(appContext(ctx), postID, categoriesInput.Categories)
+	if err != nil {
+		problemResponse(ctx, err)
+		return
+	}
+
+	err = writeJSON(ctx.Writer, http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{
+			"success": true,
+			"message": "Attach categories successful",
+		},
+	}, nil)
+	if err != nil {
+		problemResponse(ctx, err)
+		return
+	}
+}
+
+// DetachCategoryFromPost godoc
+// @Summary Detach a category from a post
+// @Description Remove a category from a post
+// @Tags categories
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param slug path string true "Category slug"
+// @Success 200 {object} map[string]interface{} "Detach category successful"
+// @Failure 400 {object} problem "Bad request error with message"
+// @Failure 403 {object} problem "Post belongs to another author"
+// @Failure 500 {object} problem "Internal server error"
+// @Router /api/v1/posts/{id}/categories/{slug} [delete]
+func (a *api) DetachCategoryFromPost(ctx *gin.Context) {
+	postID, err := getIntParam(ctx, "id")
+	if err != nil {
+		problemResponse(ctx, app.Invalid("id", "post id param is invalid"))
+		return
+	}
+
+	err = a.app.DetachCategoryFromPost(appContext(ctx), postID, ctx.Param("slug"))
+	if err != nil {
+		problemResponse(ctx, err)
+		return
+	}
+
+	err = writeJSON(ctx.Writer, http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{
+			"success": true,
+			"message": "Detach category successful",
+		},
+	}, nil)
+	if err != nil {
+		problemResponse(ctx, err)
+		return
+	}
+}
@@ -0,0 +1,236 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pooulad/blogo/internal/app"
+	"github.com/pooulad/blogo/internal/database/model"
+)
+
+// GetTrashedPosts godoc
+// @Summary Get trashed posts
+// @Description Retrieve the deleted posts which are still in the trash
+// @Tags trash
+// @Produce json
+// @Param limit query int false "Page size, 20 by default and 100 at most"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param sort query string false "newest (default) or oldest"
+// @Success 200 {object} map[string]interface{} "Trashed posts"
+// @Failure 400 {object} problem "Bad request error with message"
+// @Failure 403 {object} problem "Permission denied"
+// @Failure 500 {object} problem "Internal server error"
+// @Router /api/v1/trash/posts [get]
+func (a *api) GetTrashedPosts(ctx *gin.Context) {
+	var query model.PageQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		problemResponse(ctx, badRequest(err))
+		return
+	}
+
+	posts, page, err := a.app.GetTrashedPosts(appContext(ctx), query)
+	if err != nil {
+		problemResponse(ctx, err)
+		return
+	}
+
+	err = writeJSON(ctx.Writer, http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{
+			"success":     true,
+			"message":     "Get trashed posts successful",
+			"posts":       posts,
+			"next_cursor": page.NextCursor,
+			"has_more":    page.HasMore,
+		},
+	}, nil)
+	if err != nil {
+		problemResponse(ctx, err)
+		return
+	}
+}
+
+// GetTrashedUsers godoc
+// @Summary Get trashed users
+// @Description Retrieve the deleted users which are still in the trash
+// @Tags trash
+// @Produce json
+// @Param limit query int false "Page size, 20 by default and 100 at most"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param sort query string false "newest (default) or oldest"
+// @Success 200 {object} map[string]interface{} "Trashed users"
+// @Failure 400 {object} problem "Bad request error with message"
+// @Failure 403 {object} problem "Permission denied"
+// @Failure 500 {object} problem "Internal server error"
+// @Router /api/v1/trash/users [get]
+func (a *api) GetTrashedUsers(ctx *gin.Context) {
+	var query model.PageQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		problemResponse(ctx, badRequest(err))
+		return
+	}
+
+	users, page, err := a.app.GetTrashedUsers(appContext(ctx), query)
+	if err != nil {
+		problemResponse(ctx, err)
+		return
+	}
+
+	err = writeJSON(ctx.Writer, http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{
+			"success":     true,
+			"message":     "Get trashed users successful",
+			"users":       users,
+			"next_cursor": page.NextCursor,
+			"has_more":    page.HasMore,
+		},
+	}, nil)
+	if err != nil {
+		problemResponse(ctx, err)
+		return
+	}
+}
+
+// RestorePostByID godoc
+// @Summary Restore a trashed post
+// @Description Move a deleted post out of the trash. Posts of trashed authors can't be restored before their author
+// @Tags trash
+// @Produce json
+// @Param id path int true "Post ID"
+// @Success 200 {object} map[string]interface{} "Restore post successful"
+// @Failure 400 {object} problem "Bad request error with message"
+// @Failure 403 {object} problem "Permission denied"
+// @Failure 500 {object} problem "Internal server error"
+// @Router /api/v1/trash/posts/{id}/restore [post]
+func (a *api) RestorePostByID(ctx *gin.Context) {
+	postID, err := getIntParam(ctx, "id")
+	if err != nil {
+		problemResponse(ctx, app.Invalid("id", "post id param is invalid"))
+		return
+	}
+
+	err = a.app.RestorePostByID(appContext(ctx), postID)
+	if err != nil {
+		problemResponse(ctx, err)
+		return
+	}
+
+	err = writeJSON(ctx.Writer, http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{
+			"success": true,
+			"message": "Restore post successful",
+		},
+	}, nil)
+	if err != nil {
+		problemResponse(ctx, err)
+		return
+	}
+}
+
+// RestoreUserByID godoc
+// @Summary Restore a trashed user
+// @Description Move a deleted user out of the trash, together with the posts which were deleted with the user
+// @Tags trash
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} map[string]interface{} "Restore user successful"
+// @Failure 400 {object} problem "Bad request error with message"
+// @Failure 403 {object} problem "Permission denied"
+// @Failure 409 {object} problem "Username or email is taken by another user"
+// @Failure 500 {object} problem "Internal server error"
+// @Router /api/v1/trash/users/{id}/restore [post]
+func (a *api) RestoreUserByID(ctx *gin.Context) {
+	userID, err := getIntParam(ctx, "id")
+	if err != nil {
+		problemResponse(ctx, app.Invalid("id", "user id param is invalid"))
+		return
+	}
+
+	err = a.app.RestoreUserByID(appContext(ctx), userID)
+	if err != nil {
+		problemResponse(ctx, err)
+		return
+	}
+
+	err = writeJSON(ctx.Writer, http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{
+			"success": true,
+			"message": "Restore user successful",
+		},
+	}, nil)
+	if err != nil {
+		problemResponse(ctx, err)
+		return
+	}
+}
+
+// PurgePostByID godoc
+// @Summary Purge a trashed post
+// @Description Delete a trashed post for good, with its likes, tags, categories, comments and revisions
+// @Tags trash
+// @Produce json
+// @Param id path int true "Post ID"
+// @Success 200 {object} map[string]interface{} "Purge post successful"
+// @Failure 400 {object} problem "Bad request error with message"
+// @Failure 403 {object} problem "Permission denied"
+// @Failure 500 {object} problem "Internal server error"
+// @Router /api/v1/trash/posts/{id} [delete]
+func (a *api) PurgePostByID(ctx *gin.Context) {
+	postID, err := getIntParam(ctx, "id")
+	if err != nil {
+		problemResponse(ctx, app.Invalid("id", "post id param is invalid"))
+		return
+	}
+
+	err = a.app.PurgePostByID(appContext(ctx), postID)
+	if err != nil {
+		problemResponse(ctx, err)
+		return
+	}
+
+	err = writeJSON(ctx.Writer, http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{
+			"success": true,
+			"message": "Purge post successful",
+		},
+	}, nil)
+	if err != nil {
+		problemResponse(ctx, err)
+		return
+	}
+}
+
+// PurgeUserByID godoc
+// @Summary Purge a trashed user
+// @Description Delete a trashed user for good, with its posts, likes, follows and sessions. Comments of the user on other posts are kept as deleted placeholders
+// @Tags trash
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} map[string]interface{} "Purge user successful"
+// @Failure 400 {object} problem "Bad request error with message"
+// @Failure 403 {object} problem "Permission denied"
+// @Failure 500 {object} problem "Internal server error"
+// @Router /api/v1/trash/users/{id} [delete]
+func (a *api) PurgeUserByID(ctx *gin.Context) {
+	userID, err := getIntParam(ctx, "id")
+	if err != nil {
+		problemResponse(ctx, app.Invalid("id", "user id param is invalid"))
+		return
+	}
+
+	err = a.app.PurgeUserByID(appContext(ctx), userID)
+	if err != nil {
+		problemResponse(ctx, err)
+		return
+	}
+
+	err = writeJSON(ctx.Writer, http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{
+			"success": true,
+			"message": "Purge user successful",
+		},
+	}, nil)
+	if err != nil {
+		problemResponse(ctx, err)
+		return
+	}
+}
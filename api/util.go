@@ -1,12 +1,25 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/pooulad/blogo/internal/app"
 )
 
+// appContext returns the context of the request which carries the current
+// user to the app layer.
+func appContext(ctx *gin.Context) context.Context {
+	return app.WithActor(ctx.Request.Context(), app.Actor{
+		UserID:    ctx.GetUint("user_id"),
+		Username:  ctx.GetString("username"),
+		Role:      ctx.GetString("role"),
+		SessionID: ctx.GetUint("session_id"),
+	})
+}
+
 func GetParamByName(ctx *gin.Context, paramName string) (interface{}, error) {
 	param := ctx.Param(paramName)
 
@@ -20,3 +33,57 @@ func GetParamByName(ctx *gin.Context, paramName string) (interface{}, error) {
 
 	return param, nil
 }
+
+// getIntParam returns the path param as a number.
+func getIntParam(ctx *gin.Context, paramName string) (int, error) {
+	param, err := GetParamByName(ctx, paramName)
+	if err != nil {
+		return 0, err
+	}
+
+	id, ok := param.(int)
+	if !ok {
+		return 0, fmt.Errorf("param is not a number")
+	}
+
+	return id, nil
+}
+
+// getTargetID returns the "id" path param. the deprecated routes have no
+// path param, so for them the id is read from the legacy body field instead.
+func getTargetID(ctx *gin.Context, legacyField string) (int, error) {
+	if ctx.Param("id") != "" {
+		targetID, err := getIntParam(ctx, "id")
+		if err != nil {
+			return 0, fmt.Errorf("id param is invalid")
+		}
+
+		return targetID, nil
+	}
+
+	var body map[string]interface{}
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		return 0, fmt.Errorf("invalid request")
+	}
+
+	// json numbers are decoded as float64
+	targetID, ok := body[legacyField].(float64)
+	if !ok {
+		return 0, fmt.Errorf("%s is invalid", legacyField)
+	}
+
+	return int(targetID), nil
+}
+
+// badRequest marks an error of the request itself, such as a body which
+// can't be bound, as a validation error. failed validation rules are reported
+// per field.
+func badRequest(err error) error {
+	if fields, ok := fieldErrors(err); ok {
+		validationErr := app.Validation(fields...)
+		validationErr.Err = err
+		return validationErr
+	}
+
+	return &app.Error{Kind: app.ErrValidation, Code: "invalid_request", Message: err.Error(), Err: err}
+}
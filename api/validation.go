@@ -0,0 +1,113 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/pooulad/blogo/internal/app"
+)
+
+const minPasswordLength = 8
+
+var usernamePattern = regexp.MustCompile(`^[a-zA-Z0-9_]{3,32}$`)
+
+// registerValidations adds the custom rules to the validator of gin and makes
+// it report fields by their json names.
+func registerValidations() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" || name == "" {
+			return field.Name
+		}
+		return name
+	})
+
+	v.RegisterValidation("username", func(fl validator.FieldLevel) bool {
+		return usernamePattern.MatchString(fl.Field().String())
+	})
+	v.RegisterValidation("password", func(fl validator.FieldLevel) bool {
+		return isStrongPassword(fl.Field().String())
+	})
+}
+
+// isStrongPassword requires a password long enough with upper and lower case
+// letters and a digit.
+func isStrongPassword(password string) bool {
+	if len(password) < minPasswordLength {
+		return false
+	}
+
+	var upper, lower, digit bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		}
+	}
+
+	return upper && lower && digit
+}
+
+// fieldErrors turns the errors of a failed binding into the invalid fields of
+// the request. ok is false when the error isn't about a field.
+func fieldErrors(err error) (fields []app.FieldError, ok bool) {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		for _, fe := range validationErrs {
+			fields = append(fields, app.FieldError{
+				Field:   fe.Field(),
+				Code:    fe.Tag(),
+				Message: validationMessage(fe),
+			})
+		}
+		return fields, true
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return []app.FieldError{{
+			Field:   typeErr.Field,
+			Code:    "type",
+			Message: fmt.Sprintf("%s must be a %s", typeErr.Field, typeErr.Type),
+		}}, true
+	}
+
+	return nil, false
+}
+
+func validationMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return fmt.Sprintf("%s is required", fe.Field())
+	case "email":
+		return fmt.Sprintf("%s must be a valid email", fe.Field())
+	case "username":
+		return fmt.Sprintf("%s must be 3 to 32 letters, digits or underscores", fe.Field())
+	case "password":
+		return fmt.Sprintf("%s must be at least %d characters with upper and lower case letters and a digit", fe.Field(), minPasswordLength)
+	case "min":
+		return fmt.Sprintf("%s must be at least %s characters", fe.Field(), fe.Param())
+	case "max":
+		return fmt.Sprintf("%s must be at most %s characters", fe.Field(), fe.Param())
+	case "oneof":
+		return fmt.Sprintf("%s must be one of %s", fe.Field(), strings.ReplaceAll(fe.Param(), " ", ", "))
+	}
+
+	return fmt.Sprintf("%s is invalid", fe.Field())
+}
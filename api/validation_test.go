@@ -0,0 +1,101 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pooulad/blogo/internal/app"
+	"github.com/pooulad/blogo/internal/database/model"
+)
+
+func bindRegisterInput(t *testing.T, body string) error {
+	t.Helper()
+
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx.Request = httptest.NewRequest(http.MethodPost, "/api/v1/auth/register", strings.NewReader(body))
+	ctx.Request.Header.Set("Content-Type", "application/json")
+
+	var input model.RegisterInput
+	return ctx.ShouldBindJSON(&input)
+}
+
+func TestRegisterInputValidation(t *testing.T) {
+	registerValidations()
+
+	tests := []struct {
+		name      string
+		body      string
+		wantField string
+		wantCode  string
+	}{
+		{"missing username", `{"password":"Secret123","email":"a@example.com"}`, "username", "required"},
+		{"username charset", `{"username":"al ice","password":"Secret123","email":"a@example.com"}`, "username", "username"},
+		{"weak password", `{"username":"alice","password":"secret","email":"a@example.com"}`, "password", "password"},
+		{"malformed email", `{"username":"alice","password":"Secret123","email":"alice"}`, "email", "email"},
+		{"wrong type", `{"username":1,"password":"Secret123","email":"a@example.com"}`, "username", "type"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := bindRegisterInput(t, tt.body)
+			if err == nil {
+				t.Fatal("input is accepted")
+			}
+
+			domainErr := app.AsError(badRequest(err))
+			if domainErr == nil || domainErr.Kind != app.ErrValidation {
+				t.Fatalf("error = %v, want a validation error", domainErr)
+			}
+			if len(domainErr.Fields) != 1 {
+				t.Fatalf("fields = %+v, want one", domainErr.Fields)
+			}
+			if field := domainErr.Fields[0]; field.Field != tt.wantField || field.Code != tt.wantCode {
+				t.Errorf("field = %s/%s, want %s/%s", field.Field, field.Code, tt.wantField, tt.wantCode)
+			}
+		})
+	}
+
+	if err := bindRegisterInput(t, `{"username":"alice_1","password":"Secret123","email":"alice@example.com"}`); err != nil {
+		t.Errorf("valid input: error = %v", err)
+	}
+}
+
+func TestCommentInputValidation(t *testing.T) {
+	registerValidations()
+
+	for body, wantCode := range map[string]string{
+		`{}`:             "required",
+		`{"content":""}`: "required",
+		`{"content":"` + strings.Repeat("a", 2001) + `"}`: "max",
+	} {
+		ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+		ctx.Request = httptest.NewRequest(http.MethodPost, "/api/v1/posts/1/comments", strings.NewReader(body))
+		ctx.Request.Header.Set("Content-Type", "application/json")
+
+		var input model.CommentInput
+		domainErr := app.AsError(badRequest(ctx.ShouldBindJSON(&input)))
+		if domainErr == nil || len(domainErr.Fields) != 1 {
+			t.Fatalf("error = %v, want one invalid field", domainErr)
+		}
+		if field := domainErr.Fields[0]; field.Field != "content" || field.Code != wantCode {
+			t.Errorf("field = %s/%s, want content/%s", field.Field, field.Code, wantCode)
+		}
+	}
+}
+
+func TestIsStrongPassword(t *testing.T) {
+	for password, want := range map[string]bool{
+		"Secret123": true,
+		"secret123": false,
+		"SECRET123": false,
+		"Secretabc": false,
+		"Se1":       false,
+	} {
+		if got := isStrongPassword(password); got != want {
+			t.Errorf("isStrongPassword(%q) = %v, want %v", password, got, want)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pooulad/blogo/internal/config"
+	"github.com/pooulad/blogo/internal/database"
+)
+
+const migrateUsage = `usage: blogo [flags] migrate <command>
+
+commands:
+  up             apply every pending migration
+  down [steps]   roll back the last steps migrations, 1 by default
+  status         list migrations and when they were applied
+  create <name>  write blank up and down files for a new migration to every dialect`
+
+// runMigrate runs a migrate command and exits with a non-zero code when it
+// fails.
+func runMigrate(cfg *config.Config, args []string) {
+	if err := migrate(cfg, args); err != nil {
+		slog.Error("migrate failed", slog.Any("error", err))
+		os.Exit(1)
+	}
+}
+
+func migrate(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return fmt.Errorf("migrate command is required")
+	}
+
+	// create only writes files, it doesn't need the database
+	if args[0] == "create" {
+		if len(args) != 2 {
+			fmt.Fprintln(os.Stderr, migrateUsage)
+			return fmt.Errorf("migration name is required")
+		}
+
+		paths, err := database.CreateMigration(database.MigrationsDir, args[1])
+		if err != nil {
+			return err
+		}
+		for _, path := range paths {
+			fmt.Println(path)
+		}
+		return nil
+	}
+
+	db, err := database.Open(cfg)
+	if err != nil {
+		return err
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		defer sqlDB.Close()
+	}
+
+	ctx := context.Background()
+	switch args[0] {
+	case "up":
+		count, err := database.MigrateUp(ctx, db)
+		if err != nil {
+			return err
+		}
+		slog.Info("migrations applied", slog.Int("count", count))
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("steps must be a positive number")
+			}
+		}
+
+		count, err := database.MigrateDown(ctx, db, steps)
+		if err != nil {
+			return err
+		}
+		slog.Info("migrations rolled back", slog.Int("count", count))
+
+	case "status":
+		statuses, err := database.MigrationsStatus(ctx, db)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.Applied() {
+				appliedAt = status.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+		}
+		return w.Flush()
+
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return fmt.Errorf("unknown migrate command %s", args[0])
+	}
+
+	return nil
+}
@@ -1,18 +1,27 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/pooulad/blogo/api"
 	"github.com/pooulad/blogo/internal/app"
 	"github.com/pooulad/blogo/internal/config"
 	"github.com/pooulad/blogo/internal/database"
+	"github.com/pooulad/blogo/internal/logging"
 	"github.com/pooulad/blogo/utilities"
 )
 
 func runServer() {
-	// log layer: should add later
+	// errors before the logger is set up are printed by the standard logger
 	log.SetPrefix(fmt.Sprintf("%s --> ERORR: ", utilities.ColorizeMessage(utilities.ColorYellow, "blogo")))
 	log.SetFlags(0)
 
@@ -22,16 +31,89 @@ func runServer() {
 		log.Fatal(err)
 	}
 
+	// log layer: structured logs of the level and format in the config
+	logger, err := logging.New(os.Stdout, cfg.Log.Level, cfg.Log.Format)
+	if err != nil {
+		log.Fatal(err)
+	}
+	slog.SetDefault(logger)
+
+	// migrate layer: blogo [flags] migrate <command> manages the schema and exits
+	if flag.Arg(0) == "migrate" {
+		runMigrate(cfg, flag.Args()[1:])
+		return
+	}
+
 	// store layer: connect DB and use
 	store, err := database.Connect(cfg)
 	if err != nil {
-		log.Fatal(err)
+		slog.Error("connect database failed", slog.Any("error", err))
+		os.Exit(1)
 	}
 
 	// application layer: handle logic of program
-	app := app.New(store, cfg)
+	app := app.New(store.Repositories, store, cfg)
+
+	// ctx is cancelled on SIGINT or SIGTERM and stops the background jobs
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// jobs are the background jobs, they are waited for before the database
+	// pool is closed
+	var jobs sync.WaitGroup
+
+	// scheduler layer: publish scheduled posts in the background
+	jobs.Add(1)
+	go func() {
+		defer jobs.Done()
+		app.RunScheduler(ctx, time.Minute)
+	}()
+
+	// retention layer: purge posts and users which stayed too long in the trash
+	retention := time.Duration(cfg.TrashRetentionDays) * 24 * time.Hour
+	jobs.Add(1)
+	go func() {
+		defer jobs.Done()
+		app.RunTrashRetention(ctx, time.Hour, retention)
+	}()
 
 	// http/api layer: handle http/api requests
 	api := api.New(app)
-	log.Fatal(api.Start())
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- api.Start()
+	}()
+
+	exitCode := 0
+	select {
+	case err := <-serverErr:
+		if err != nil {
+			slog.Error("server stopped", slog.Any("error", err))
+			exitCode = 1
+		}
+	case <-ctx.Done():
+		// a second signal kills the process right away
+		stop()
+		grace := time.Duration(cfg.ShutdownGraceSeconds) * time.Second
+		slog.Info("shutting down", slog.Duration("grace", grace))
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), grace)
+		defer cancel()
+		if err := api.Shutdown(shutdownCtx); err != nil {
+			slog.Error("drain connections failed", slog.Any("error", err))
+		}
+	}
+
+	// stop the jobs and let their current queries finish or abort
+	stop()
+	jobs.Wait()
+
+	if err := app.Close(); err != nil {
+		slog.Error("close database failed", slog.Any("error", err))
+	}
+	slog.Info("server stopped")
+
+	if exitCode != 0 {
+		os.Exit(exitCode)
+	}
 }
@@ -0,0 +1,24 @@
+package app
+
+import "context"
+
+// Actor is the user a call is made for. anonymous calls carry the zero Actor.
+type Actor struct {
+	UserID    uint
+	Username  string
+	Role      string
+	SessionID uint
+}
+
+type actorKey struct{}
+
+// WithActor returns a copy of ctx which carries the actor.
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFrom returns the actor of ctx, the zero Actor when there is none.
+func ActorFrom(ctx context.Context) Actor {
+	actor, _ := ctx.Value(actorKey{}).(Actor)
+	return actor
+}
@@ -1,53 +1,112 @@
 package app
 
 import (
+	"context"
+	"errors"
 	"fmt"
 
-	"github.com/gin-gonic/gin"
 	"github.com/pooulad/blogo/internal/config"
-	"github.com/pooulad/blogo/internal/database"
 	"github.com/pooulad/blogo/internal/database/model"
+	"github.com/pooulad/blogo/internal/database/repository"
+	"github.com/pooulad/blogo/internal/feed"
+	"github.com/pooulad/blogo/internal/metrics"
+	"github.com/pooulad/blogo/internal/render"
+	"github.com/pooulad/blogo/utilities"
 	"golang.org/x/crypto/bcrypt"
 )
 
 const (
-	defaultRole = "user"
+	defaultRole = model.RoleUser
 )
 
 type App interface {
 	// return app config
 	GetConfig() *config.Config
+	// health
+	Ready(ctx context.Context) error
+	Close() error
 	// user crud
-	GetAllUsers(ctx *gin.Context) (*[]model.UserResponse, error)
-	CreateUser(ctx *gin.Context, user *model.User) error
-	UpdateUserByID(ctx *gin.Context, userID int) error
-	DeleteUserByID(ctx *gin.Context, userID int) error
-	GetUserByID(ctx *gin.Context, userID int) (*model.User, error)
-	FollowUserByID(ctx *gin.Context) error
-	UnFollowUserByID(ctx *gin.Context) error
-	GetFollowersByID(ctx *gin.Context, userID int) (*[]model.UserResponse, error)
-	GetFollowingByID(ctx *gin.Context, userID int) (*[]model.UserResponse, error)
+	GetAllUsers(ctx context.Context, query model.UserQuery) (*[]model.UserResponse, *model.Page, error)
+	CreateUser(ctx context.Context, user *model.User) error
+	UpdateUserByID(ctx context.Context, userID int, input model.UpdateUserInput) error
+	DeleteUserByID(ctx context.Context, userID int) error
+	GetUserByID(ctx context.Context, userID int) (*model.User, error)
+	GetUserByUsername(ctx context.Context, username string) (*model.User, error)
+	FollowUserByID(ctx context.Context, userID int) error
+	UnFollowUserByID(ctx context.Context, userID int) error
+	GetFollowersByID(ctx context.Context, userID int, query model.PageQuery) (*[]model.UserResponse, *model.Page, error)
+	GetFollowingByID(ctx context.Context, userID int, query model.PageQuery) (*[]model.UserResponse, *model.Page, error)
 	// post crud
-	CreatePost(ctx *gin.Context, post *model.Post) error
-	GetAllPosts(ctx *gin.Context) (*[]model.PostResponse, error)
-	UpdatePostByID(ctx *gin.Context, postID int) error
-	DeletePostByID(ctx *gin.Context, postID int) error
-	GetPostByID(ctx *gin.Context, postID int) (*model.PostResponse, error)
-	LikePostByID(ctx *gin.Context) error
-	UnlikePostByID(ctx *gin.Context) error
+	CreatePost(ctx context.Context, post *model.Post) error
+	GetAllPosts(ctx context.Context, query model.PostQuery) (*[]model.PostResponse, *model.Page, error)
+	UpdatePostByID(ctx context.Context, postID int, input model.UpdatePostInput) error
+	DeletePostByID(ctx context.Context, postID int) error
+	TransferPostByID(ctx context.Context, postID int, userID uint) error
+	GetPostByID(ctx context.Context, postID int) (*model.PostResponse, error)
+	GetPostBySlug(ctx context.Context, slug string) (*model.PostResponse, string, error)
+	LikePostByID(ctx context.Context, postID int) error
+	UnlikePostByID(ctx context.Context, postID int) error
+	// post revisions
+	GetPostRevisions(ctx context.Context, postID int, query model.PageQuery) (*[]model.PostRevision, *model.Page, error)
+	DiffPostRevisions(ctx context.Context, postID int, query model.RevisionDiffQuery) (*model.RevisionDiff, error)
+	RestorePostRevision(ctx context.Context, postID int, number int) error
+	// feed
+	GetHomeFeed(ctx context.Context, query model.PageQuery) (*[]model.PostResponse, *model.Page, error)
+	GetPostsFeed(ctx context.Context, query model.FeedQuery) (*feed.Feed, error)
+	// tags and categories
+	AttachTagsToPost(ctx context.Context, postID int, names []string) error
+	DetachTagFromPost(ctx context.Context, postID int, slug string) error
+	GetTagCounts(ctx context.Context) (*[]model.TagCount, error)
+	MergeTags(ctx context.Context, from, into string) error
+	CreateCategory(ctx context.Context, category *model.Category) error
+	GetCategoryCounts(ctx context.Context) (*[]model.CategoryCount, error)
+	AttachCategoriesToPost(ctx context.Context, postID int, slugs []string) error
+	DetachCategoryFromPost(ctx context.Context, postID int, slug string) error
+	// comments
+	GetCommentsByPostID(ctx context.Context, postID int) (*[]*model.CommentResponse, error)
+	CreateComment(ctx context.Context, postID int, commentInput model.CommentInput) (*model.Comment, error)
+	UpdateCommentByID(ctx context.Context, postID, commentID int, commentInput model.CommentInput) error
+	DeleteCommentByID(ctx context.Context, postID, commentID int) error
+	// trash
+	GetTrashedPosts(ctx context.Context, query model.PageQuery) (*[]model.Post, *model.Page, error)
+	GetTrashedUsers(ctx context.Context, query model.PageQuery) (*[]model.UserResponse, *model.Page, error)
+	RestorePostByID(ctx context.Context, postID int) error
+	RestoreUserByID(ctx context.Context, userID int) error
+	PurgePostByID(ctx context.Context, postID int) error
+	PurgeUserByID(ctx context.Context, userID int) error
+	// search
+	SearchPosts(ctx context.Context, query model.SearchQuery) (*[]model.PostSearchResult, *model.Page, error)
+	SearchUsers(ctx context.Context, query model.SearchQuery) (*[]model.UserSearchResult, *model.Page, error)
 	// authentication
-	Register(ctx *gin.Context, registerInput model.RegisterInput) (*model.UserResponse, error)
-	Login(ctx *gin.Context, loginInput model.LoginInput) (*model.UserResponse, error)
+	Register(ctx context.Context, registerInput model.RegisterInput) (*model.UserResponse, error)
+	Login(ctx context.Context, loginInput model.LoginInput) (*model.UserResponse, error)
+	// sessions
+	CreateSession(ctx context.Context, input model.SessionInput) (*model.Session, string, error)
+	RefreshSession(ctx context.Context, refreshToken string) (*model.Session, string, error)
+	IsSessionActive(ctx context.Context, sessionID uint) (bool, error)
+	Logout(ctx context.Context, sessionID uint) error
+	LogoutAll(ctx context.Context, sessionID uint) error
+}
+
+// Database is what the app needs from the database itself, beside the
+// repositories which read and write its rows.
+type Database interface {
+	Ping(ctx context.Context) error
+	// Migrated reports whether every migration has been applied.
+	Migrated(ctx context.Context) (bool, error)
+	Close() error
 }
 
 type app struct {
-	store  *database.Store
+	store  repository.Repositories
+	db     Database
 	config *config.Config
 }
 
-func New(store *database.Store, config *config.Config) *app {
+func New(store repository.Repositories, db Database, config *config.Config) *app {
 	return &app{
 		store:  store,
+		db:     db,
 		config: config,
 	}
 }
@@ -56,51 +115,68 @@ func (a *app) GetConfig() *config.Config {
 	return a.config
 }
 
-func (a *app) GetAllUsers(ctx *gin.Context) (*[]model.UserResponse, error) {
-	users, err := a.store.Model.UserResponse.GetAllUsers(a.store.DB)
+func (a *app) GetAllUsers(ctx context.Context, query model.UserQuery) (*[]model.UserResponse, *model.Page, error) {
+	users, page, err := a.store.Users.List(ctx, ActorFrom(ctx).UserID, query)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return users, nil
+	hideEmails(ctx, users)
+
+	return &users, page, nil
 }
 
-func (a *app) GetFollowersByID(ctx *gin.Context, userID int) (*[]model.UserResponse, error) {
-	followers, err := a.store.Model.User.GetFollowers(a.store.DB, userID)
+func (a *app) GetFollowersByID(ctx context.Context, userID int, query model.PageQuery) (*[]model.UserResponse, *model.Page, error) {
+	followers, page, err := a.store.Follows.Followers(ctx, uint(userID), ActorFrom(ctx).UserID, query)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return followers, nil
+	hideEmails(ctx, followers)
+
+	return &followers, page, nil
 }
 
-func (a *app) GetFollowingByID(ctx *gin.Context, userID int) (*[]model.UserResponse, error) {
-	following, err := a.store.Model.User.GetFollowings(a.store.DB, userID)
+func (a *app) GetFollowingByID(ctx context.Context, userID int, query model.PageQuery) (*[]model.UserResponse, *model.Page, error) {
+	following, page, err := a.store.Follows.Following(ctx, uint(userID), ActorFrom(ctx).UserID, query)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return following, nil
+	hideEmails(ctx, following)
+
+	return &following, page, nil
 }
 
-func (a *app) CreateUser(ctx *gin.Context, userBody *model.User) error {
-	isUserExist, err := a.store.Model.User.IsUserExistByUsername(a.store.DB, userBody)
-	if err != nil {
+func (a *app) CreateUser(ctx context.Context, userBody *model.User) error {
+	_, err := a.store.Users.GetByUsername(ctx, userBody.Username)
+	if err == nil {
+		return Conflict("user_exists", "user already exist")
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return err
+	}
+
+	if err := a.checkEmailFree(ctx, userBody.Email, 0); err != nil {
 		return err
 	}
 
-	if isUserExist {
-		return fmt.Errorf("user already exist")
+	if userBody.Role == "" {
+		userBody.Role = defaultRole
+	}
+
+	if !IsValidRole(userBody.Role) {
+		return Invalid("role", "role is invalid")
 	}
 
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(userBody.Password), bcrypt.DefaultCost)
 	if err != nil {
-		return fmt.Errorf("internal server error")
+		return fmt.Errorf("hash password: %w", err)
 	}
 
 	userBody.Password = string(hashedPassword)
 
-	err = a.store.Model.User.CreateUser(a.store.DB, userBody)
+	err = a.store.Users.Create(ctx, userBody)
 	if err != nil {
 		return err
 	}
@@ -108,49 +184,55 @@ func (a *app) CreateUser(ctx *gin.Context, userBody *model.User) error {
 	return nil
 }
 
-func (a *app) UpdateUserByID(ctx *gin.Context, userID int) error {
-	var user model.User
-	var updateFields map[string]interface{}
-
-	if err := ctx.ShouldBindJSON(&updateFields); err != nil {
-		return fmt.Errorf("invalid request")
+func (a *app) UpdateUserByID(ctx context.Context, userID int, input model.UpdateUserInput) error {
+	user, err := a.store.Users.GetByID(ctx, uint(userID), ActorFrom(ctx).UserID)
+	if err != nil {
+		return err
 	}
 
-	if err := a.store.DB.Where("id=?", userID).Find(&user).Error; err != nil {
-		return err
+	role := ActorFrom(ctx).Role
+	if user.ID != ActorFrom(ctx).UserID && !HasPermission(role, PermUsersUpdateAny) {
+		return ErrPermissionDenied
 	}
 
-	if user.ID == 0 {
-		return fmt.Errorf("user not found")
+	// only admins may change the role or (de)activate an account
+	if (input.Role != nil || input.Active != nil) && !HasPermission(role, PermUsersManage) {
+		return ErrPermissionDenied
 	}
 
-	if firstName, ok := updateFields["first_name"]; ok {
-		user.FirstName = firstName.(string)
+	if input.FirstName != nil {
+		user.FirstName = *input.FirstName
 	}
-	if lastName, ok := updateFields["last_name"]; ok {
-		user.LastName = lastName.(string)
+	if input.LastName != nil {
+		user.LastName = *input.LastName
 	}
-	if active, ok := updateFields["active"]; ok {
-		user.Active = active.(bool)
+	if input.Active != nil {
+		user.Active = *input.Active
 	}
-	if email, ok := updateFields["email"]; ok {
-		user.Email = email.(string)
+	if input.Email != nil {
+		if err := a.checkEmailFree(ctx, *input.Email, user.ID); err != nil {
+			return err
+		}
+		user.Email = *input.Email
 	}
-	if role, ok := updateFields["role"]; ok {
-		user.Role = role.(string)
+	if input.Role != nil {
+		if !IsValidRole(*input.Role) {
+			return Invalid("role", "role is invalid")
+		}
+		user.Role = *input.Role
 	}
-	if skill, ok := updateFields["skill"]; ok {
-		user.Skill = skill.(string)
+	if input.Skill != nil {
+		user.Skill = *input.Skill
 	}
-	if password, ok := updateFields["password"]; ok {
-		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password.(string)), bcrypt.DefaultCost)
+	if input.Password != nil {
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(*input.Password), bcrypt.DefaultCost)
 		if err != nil {
-			return fmt.Errorf("internal server error")
+			return fmt.Errorf("hash password: %w", err)
 		}
 		user.Password = string(hashedPassword)
 	}
 
-	err := a.store.Model.User.UpdateUserByID(a.store.DB, &user)
+	err = a.store.Users.Update(ctx, user)
 	if err != nil {
 		return err
 	}
@@ -158,29 +240,59 @@ func (a *app) UpdateUserByID(ctx *gin.Context, userID int) error {
 	return nil
 }
 
-func (a *app) GetUserByID(ctx *gin.Context, userID int) (*model.User, error) {
-	user, err := a.store.Model.User.GetUserByID(a.store.DB, userID)
+func (a *app) GetUserByID(ctx context.Context, userID int) (*model.User, error) {
+	user, err := a.store.Users.GetByID(ctx, uint(userID), ActorFrom(ctx).UserID)
 	if err != nil {
 		return nil, err
 	}
 
+	// the password hash never leaves the server
+	user.Password = ""
+	if ActorFrom(ctx).UserID == 0 {
+		user.Email = ""
+	}
+
 	return user, nil
 }
 
-func (a *app) FollowUserByID(ctx *gin.Context) error {
-	type body struct {
-		FollowerID uint `json:"follower_id"`
-		FollowedID uint `json:"followed_id"`
+func (a *app) GetUserByUsername(ctx context.Context, username string) (*model.User, error) {
+	return a.store.Users.GetByUsername(ctx, username)
+}
+
+// checkEmailFree returns ErrEmailTaken when another user than exceptUserID
+// has the email.
+func (a *app) checkEmailFree(ctx context.Context, email string, exceptUserID uint) error {
+	taken, err := a.store.Users.IsEmailTaken(ctx, email, exceptUserID)
+	if err != nil {
+		return err
 	}
+	if taken {
+		return ErrEmailTaken
+	}
+
+	return nil
+}
 
-	var requestBody body
+func (a *app) FollowUserByID(ctx context.Context, userID int) error {
+	followerID := ActorFrom(ctx).UserID
+	if followerID == uint(userID) {
+		return ErrSelfFollow
+	}
 
-	err := ctx.ShouldBindJSON(&requestBody)
+	if _, err := a.store.Users.GetByID(ctx, uint(userID), followerID); err != nil {
+		return err
+	}
+
+	isFollowed, err := a.store.Follows.IsFollowing(ctx, followerID, uint(userID))
 	if err != nil {
 		return err
 	}
 
-	err = a.store.Model.User.FollowUserByID(a.store.DB, requestBody.FollowerID, requestBody.FollowedID)
+	if isFollowed {
+		return ErrAlreadyFollowed
+	}
+
+	err = a.store.Follows.Follow(ctx, followerID, uint(userID))
 	if err != nil {
 		return err
 	}
@@ -188,20 +300,22 @@ func (a *app) FollowUserByID(ctx *gin.Context) error {
 	return nil
 }
 
-func (a *app) UnFollowUserByID(ctx *gin.Context) error {
-	type body struct {
-		FollowerID uint `json:"follower_id"`
-		FollowedID uint `json:"followed_id"`
+func (a *app) UnFollowUserByID(ctx context.Context, userID int) error {
+	err := a.store.Follows.Unfollow(ctx, ActorFrom(ctx).UserID, uint(userID))
+	if err != nil {
+		return err
 	}
 
-	var requestBody body
+	return nil
+}
 
-	err := ctx.ShouldBindJSON(&requestBody)
+func (a *app) DeleteUserByID(ctx context.Context, userID int) error {
+	err := a.store.Users.Delete(ctx, uint(userID))
 	if err != nil {
 		return err
 	}
 
-	err = a.store.Model.User.UnFollowUserByID(a.store.DB, requestBody.FollowerID, requestBody.FollowedID)
+	err = a.store.Sessions.RevokeByUserID(ctx, uint(userID))
 	if err != nil {
 		return err
 	}
@@ -209,100 +323,155 @@ func (a *app) UnFollowUserByID(ctx *gin.Context) error {
 	return nil
 }
 
-func (a *app) DeleteUserByID(ctx *gin.Context, userID int) error {
-	err := a.store.Model.User.DeleteUserByID(a.store.DB, userID)
+func (a *app) CreatePost(ctx context.Context, postBody *model.Post) error {
+	// the author is always the current user, whatever the body says
+	postBody.UserRefer = ActorFrom(ctx).UserID
+
+	err := setPostStatus(postBody, postBody.Status, postBody.PublishedAt)
 	if err != nil {
 		return err
 	}
 
-	return nil
-}
+	if postBody.Format == "" {
+		postBody.Format = render.FormatMarkdown
+	}
+
+	err = renderPostContent(postBody)
+	if err != nil {
+		return err
+	}
 
-func (a *app) CreatePost(ctx *gin.Context, postBody *model.Post) error {
-	err := a.store.Model.Post.CreatePost(a.store.DB, postBody)
+	slug, err := a.newPostSlug(ctx, postBody)
 	if err != nil {
 		return err
 	}
+	postBody.Slug = slug
+
+	err = a.store.Posts.Create(ctx, postBody)
+	if err != nil {
+		return err
+	}
+	metrics.PostsCreated.Inc()
 
 	return nil
 }
 
-func (a *app) GetAllPosts(ctx *gin.Context) (*[]model.PostResponse, error) {
+func (a *app) GetAllPosts(ctx context.Context, query model.PostQuery) (*[]model.PostResponse, *model.Page, error) {
 	var response []model.PostResponse
-	posts, err := a.store.Model.Post.GetAllPosts(a.store.DB)
+	if query.Tag != "" {
+		tag, err := a.store.Tags.ResolveSlug(ctx, query.Tag)
+		if err != nil {
+			return nil, nil, err
+		}
+		query.Tag = tag
+	}
+	query.Category = utilities.Slugify(query.Category)
+
+	posts, page, err := a.store.Posts.List(ctx, ActorFrom(ctx).UserID, query)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	username, exist := ctx.Get("username")
-	if username == nil {
-		return nil, fmt.Errorf("user id not found in context")
+	// anonymous visitors have no user id, so they never liked a post
+	userID := ActorFrom(ctx).UserID
+
+	postIDs := make([]uint, 0, len(posts))
+	for _, post := range posts {
+		postIDs = append(postIDs, post.ID)
 	}
 
-	var user model.User
-	user.Username = username.(string)
-	isUserExist, err := a.store.Model.User.IsUserExistByUsername(a.store.DB, &user)
+	commentCounts, err := a.store.Comments.CountByPostIDs(ctx, postIDs)
 	if err != nil {
-		return nil, fmt.Errorf("get current user data faild")
+		return nil, nil, err
 	}
 
-	if !isUserExist {
-		return nil, fmt.Errorf("get current user data faild")
-	}
+	for _, post := range posts {
+		ensurePostRendered(&post)
 
-	for _, post := range *posts {
 		liked := false
 		for _, userRefer := range (post).LikedBy {
-			if exist {
-				if userRefer.ID == user.ID {
-					liked = true
-					break
-				}
+			if userID != 0 && userRefer.ID == userID {
+				liked = true
+				break
 			}
 		}
 
 		response = append(response, model.PostResponse{
-			ID:         post.ID,
-			Title:      post.Title,
-			Content:    post.Content,
-			UserRefer:  post.UserRefer,
-			Liked:      liked,
-			LikedCount: len(post.LikedBy),
+			ID:           post.ID,
+			Title:        post.Title,
+			Slug:         post.Slug,
+			Content:      post.Content,
+			Format:       post.Format,
+			ContentHTML:  post.ContentHTML,
+			Status:       post.Status,
+			PublishedAt:  post.PublishedAt,
+			UserRefer:    post.UserRefer,
+			Liked:        liked,
+			LikedCount:   len(post.LikedBy),
+			CommentCount: commentCounts[post.ID],
+			Tags:         post.Tags,
+			Categories:   post.Categories,
 		})
 	}
 
-	return &response, nil
+	return &response, page, nil
 }
 
-func (a *app) UpdatePostByID(ctx *gin.Context, postID int) error {
-	var post model.Post
-	var updateFields map[string]interface{}
+func (a *app) UpdatePostByID(ctx context.Context, postID int, input model.UpdatePostInput) error {
+	post, err := a.store.Posts.GetByID(ctx, uint(postID))
+	if err != nil {
+		return err
+	}
+
+	if !canManagePost(ctx, post, PermPostsUpdateAny) {
+		return ErrPermissionDenied
+	}
 
-	if err := ctx.ShouldBindJSON(&updateFields); err != nil {
-		return fmt.Errorf("invalid request")
+	if input.UserID != nil {
+		return Invalid("user_id", "user_id can't be updated, transfer the post instead")
 	}
 
-	if err := a.store.DB.Where("id=?", postID).Find(&post).Error; err != nil {
-		return err
+	if input.Title != nil {
+		post.Title = *input.Title
 	}
 
-	if post.ID == 0 {
-		return fmt.Errorf("post not found")
+	if input.Content != nil {
+		post.Content = *input.Content
 	}
 
-	if title, ok := updateFields["title"]; ok {
-		post.Title = title.(string)
+	if input.Format != nil {
+		post.Format = *input.Format
 	}
 
-	if content, ok := updateFields["content"]; ok {
-		post.Content = content.(string)
+	if input.Content != nil || input.Format != nil {
+		if err := renderPostContent(post); err != nil {
+			return err
+		}
 	}
 
-	if userRefer, ok := updateFields["user_id"]; ok {
-		post.UserRefer = userRefer.(uint)
+	if input.Status != nil || input.PublishedAt != nil {
+		status := post.Status
+		if input.Status != nil {
+			status = *input.Status
+		}
+
+		publishedAt := post.PublishedAt
+		if input.PublishedAt != nil {
+			publishedAt = input.PublishedAt
+		}
+
+		if err := setPostStatus(post, status, publishedAt); err != nil {
+			return err
+		}
+	}
+
+	if input.Slug != nil {
+		if err := a.changePostSlug(ctx, post, *input.Slug); err != nil {
+			return err
+		}
 	}
 
-	err := a.store.Model.Post.UpdatePostByID(a.store.DB, &post)
+	err = a.store.Posts.Update(ctx, post, ActorFrom(ctx).UserID)
 	if err != nil {
 		return err
 	}
@@ -310,8 +479,17 @@ func (a *app) UpdatePostByID(ctx *gin.Context, postID int) error {
 	return nil
 }
 
-func (a *app) DeletePostByID(ctx *gin.Context, postID int) error {
-	err := a.store.Model.Post.DeletePostByID(a.store.DB, postID)
+func (a *app) DeletePostByID(ctx context.Context, postID int) error {
+	post, err := a.store.Posts.GetByID(ctx, uint(postID))
+	if err != nil {
+		return err
+	}
+
+	if !canManagePost(ctx, post, PermPostsDeleteAny) {
+		return ErrPermissionDenied
+	}
+
+	err = a.store.Posts.Delete(ctx, uint(postID))
 	if err != nil {
 		return err
 	}
@@ -319,84 +497,107 @@ func (a *app) DeletePostByID(ctx *gin.Context, postID int) error {
 	return nil
 }
 
-func (a *app) GetPostByID(ctx *gin.Context, postID int) (*model.PostResponse, error) {
-	var response model.PostResponse
-	username, exist := ctx.Get("username")
-	if username == nil {
-		return nil, fmt.Errorf("user id not found in context")
+func (a *app) TransferPostByID(ctx context.Context, postID int, userID uint) error {
+	if !HasPermission(ActorFrom(ctx).Role, PermPostsTransfer) {
+		return ErrPermissionDenied
 	}
 
-	var user model.User
-	user.Username = username.(string)
-	isUserExist, err := a.store.Model.User.IsUserExistByUsername(a.store.DB, &user)
+	post, err := a.store.Posts.GetByID(ctx, uint(postID))
 	if err != nil {
-		return nil, fmt.Errorf("get current user data faild")
+		return err
 	}
 
-	if !isUserExist {
-		return nil, fmt.Errorf("get current user data faild")
+	if _, err := a.store.Users.GetByID(ctx, userID, ActorFrom(ctx).UserID); err != nil {
+		return err
 	}
 
-	post, err := a.store.Model.Post.GetPostByID(a.store.DB, postID)
+	err = a.store.Posts.Transfer(ctx, post.ID, userID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (a *app) GetPostByID(ctx context.Context, postID int) (*model.PostResponse, error) {
+	var response model.PostResponse
+	// anonymous visitors have no user id, so they never liked a post
+	userID := ActorFrom(ctx).UserID
+
+	post, err := a.store.Posts.GetByID(ctx, uint(postID))
+	if err != nil {
+		return nil, err
+	}
+
+	if !post.IsVisibleTo(userID) {
+		return nil, NotFound("post")
+	}
+
+	ensurePostRendered(post)
+
+	commentCounts, err := a.store.Comments.CountByPostIDs(ctx, []uint{post.ID})
 	if err != nil {
 		return nil, err
 	}
 
 	liked := false
 	for _, userRefer := range post.LikedBy {
-		if exist {
-			if userRefer.ID == user.ID {
-				liked = true
-				break
-			}
+		if userID != 0 && userRefer.ID == userID {
+			liked = true
+			break
 		}
 	}
 
 	response.ID = post.ID
 	response.Title = post.Title
+	response.Slug = post.Slug
 	response.Content = post.Content
+	response.Format = post.Format
+	response.ContentHTML = post.ContentHTML
+	response.Status = post.Status
+	response.PublishedAt = post.PublishedAt
 	response.UserRefer = post.UserRefer
 	response.Liked = liked
 	response.LikedCount = len(post.LikedBy)
+	response.CommentCount = commentCounts[post.ID]
+	response.Tags = post.Tags
+	response.Categories = post.Categories
 
 	return &response, nil
 }
 
-func (a *app) LikePostByID(ctx *gin.Context) error {
-	type body struct {
-		UserID uint `json:"user_id"`
-		PostID uint `json:"post_id"`
-	}
+func (a *app) LikePostByID(ctx context.Context, postID int) error {
+	userID := ActorFrom(ctx).UserID
 
-	var requestBody body
-
-	err := ctx.ShouldBindJSON(&requestBody)
+	post, err := a.store.Posts.GetByID(ctx, uint(postID))
 	if err != nil {
 		return err
 	}
 
-	err = a.store.Model.Post.LikePostByID(a.store.DB, requestBody.UserID, requestBody.PostID)
+	if !post.IsVisibleTo(userID) {
+		return NotFound("post")
+	}
+
+	isLiked, err := a.store.Posts.IsLiked(ctx, userID, uint(postID))
 	if err != nil {
 		return err
 	}
 
-	return nil
-}
-
-func (a *app) UnlikePostByID(ctx *gin.Context) error {
-	type body struct {
-		UserID uint `json:"user_id"`
-		PostID uint `json:"post_id"`
+	if isLiked {
+		return ErrAlreadyLiked
 	}
 
-	var requestBody body
-
-	err := ctx.ShouldBindJSON(&requestBody)
+	err = a.store.Posts.Like(ctx, userID, uint(postID))
 	if err != nil {
 		return err
 	}
+	metrics.Likes.Inc()
 
-	err = a.store.Model.Post.UnlikePostByID(a.store.DB, requestBody.UserID, requestBody.PostID)
+	return nil
+}
+
+func (a *app) UnlikePostByID(ctx context.Context, postID int) error {
+	err := a.store.Posts.Unlike(ctx, ActorFrom(ctx).UserID, uint(postID))
 	if err != nil {
 		return err
 	}
@@ -404,20 +605,24 @@ func (a *app) UnlikePostByID(ctx *gin.Context) error {
 	return nil
 }
 
-func (a *app) Register(ctx *gin.Context, registerInput model.RegisterInput) (*model.UserResponse, error) {
+func (a *app) Register(ctx context.Context, registerInput model.RegisterInput) (*model.UserResponse, error) {
 	var user model.User
 	var userResponse model.UserResponse
-	if err := a.store.DB.Where("username=?", registerInput.Username).Find(&user).Error; err != nil {
+	_, err := a.store.Users.GetByUsername(ctx, registerInput.Username)
+	if err == nil {
+		return nil, Conflict("user_exists", "user already exist")
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
 		return nil, err
 	}
 
-	if user.ID != 0 {
-		return nil, fmt.Errorf("user already exist")
+	if err := a.checkEmailFree(ctx, registerInput.Email, 0); err != nil {
+		return nil, err
 	}
 
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(registerInput.Password), bcrypt.DefaultCost)
 	if err != nil {
-		return nil, fmt.Errorf("internal server error")
+		return nil, fmt.Errorf("hash password: %w", err)
 	}
 
 	// fill and create with form data here
@@ -428,10 +633,14 @@ func (a *app) Register(ctx *gin.Context, registerInput model.RegisterInput) (*mo
 	user.Email = registerInput.Email
 	user.Skill = registerInput.Skill
 	user.Role = defaultRole
+	if a.config.AdminUsername != "" && user.Username == a.config.AdminUsername {
+		user.Role = model.RoleAdmin
+	}
 
-	if err := a.store.DB.Create(&user).Error; err != nil {
-		return nil, fmt.Errorf("internal server error")
+	if err := a.store.Users.Create(ctx, &user); err != nil {
+		return nil, err
 	}
+	metrics.Registrations.Inc()
 
 	// fill user response data here
 	userResponse.ID = user.ID
@@ -440,26 +649,28 @@ func (a *app) Register(ctx *gin.Context, registerInput model.RegisterInput) (*mo
 	userResponse.Username = registerInput.Username
 	userResponse.Email = registerInput.Email
 	userResponse.Skill = registerInput.Skill
-	userResponse.Role = defaultRole
+	userResponse.Role = user.Role
 
 	return &userResponse, nil
 }
 
-func (a *app) Login(ctx *gin.Context, loginInput model.LoginInput) (*model.UserResponse, error) {
-	var user model.User
+func (a *app) Login(ctx context.Context, loginInput model.LoginInput) (*model.UserResponse, error) {
 	var userResponse model.UserResponse
-	if err := a.store.DB.Where("username=?", loginInput.Username).Find(&user).Error; err != nil {
-		return nil, fmt.Errorf("database error")
+	user, err := a.store.Users.GetByUsername(ctx, loginInput.Username)
+	if errors.Is(err, repository.ErrNotFound) {
+		metrics.Logins.WithLabelValues("failure").Inc()
+		return nil, Unauthorized("invalid_credentials", "user not found")
 	}
-
-	if user.ID == 0 {
-		return nil, fmt.Errorf("user not found")
+	if err != nil {
+		return nil, err
 	}
 
 	compaireErr := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(loginInput.Password))
 	if compaireErr != nil {
-		return nil, fmt.Errorf("password is invalid")
+		metrics.Logins.WithLabelValues("failure").Inc()
+		return nil, Unauthorized("invalid_credentials", "password is invalid")
 	}
+	metrics.Logins.WithLabelValues("success").Inc()
 
 	userResponse.ID = user.ID
 	userResponse.FirstName = user.FirstName
@@ -467,8 +678,20 @@ func (a *app) Login(ctx *gin.Context, loginInput model.LoginInput) (*model.UserR
 	userResponse.Username = user.Username
 	userResponse.Email = user.Email
 	userResponse.Skill = user.Skill
-	userResponse.Email = user.Email
+	userResponse.Role = user.Role
 	userResponse.LastVisited = user.LastVisited
 
 	return &userResponse, nil
 }
+
+// hideEmails clears the emails of the users for anonymous visitors, emails
+// are only shown to signed in users.
+func hideEmails(ctx context.Context, users []model.UserResponse) {
+	if ActorFrom(ctx).UserID != 0 {
+		return
+	}
+
+	for i := range users {
+		users[i].Email = ""
+	}
+}
@@ -0,0 +1,184 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/pooulad/blogo/internal/config"
+	"github.com/pooulad/blogo/internal/database/model"
+	"github.com/pooulad/blogo/internal/database/repository/memory"
+)
+
+func newTestApp(t *testing.T) *app {
+	t.Helper()
+
+	return New(memory.New(), nil, &config.Config{})
+}
+
+func newTestUser(t *testing.T, a *app, username, role string) context.Context {
+	t.Helper()
+
+	user := &model.User{Username: username, Role: role}
+	if err := a.store.Users.Create(context.Background(), user); err != nil {
+		t.Fatal(err)
+	}
+
+	return WithActor(context.Background(), Actor{UserID: user.ID, Username: username, Role: role})
+}
+
+func TestFollowUserByID(t *testing.T) {
+	a := newTestApp(t)
+	alice := newTestUser(t, a, "alice", model.RoleUser)
+	bob := newTestUser(t, a, "bob", model.RoleUser)
+	aliceID := int(ActorFrom(alice).UserID)
+
+	if err := a.FollowUserByID(alice, aliceID); !errors.Is(err, ErrSelfFollow) {
+		t.Fatalf("follow self: error = %v, want ErrSelfFollow", err)
+	}
+	if err := a.FollowUserByID(bob, aliceID); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.FollowUserByID(bob, aliceID); !errors.Is(err, ErrAlreadyFollowed) {
+		t.Fatalf("follow twice: error = %v, want ErrAlreadyFollowed", err)
+	}
+
+	followers, _, err := a.GetFollowersByID(context.Background(), aliceID, model.PageQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*followers) != 1 || (*followers)[0].Email != "" {
+		t.Fatalf("anonymous visitor sees %d followers or their emails", len(*followers))
+	}
+}
+
+func TestUpdateUserByIDPermissions(t *testing.T) {
+	a := newTestApp(t)
+	alice := newTestUser(t, a, "alice", model.RoleUser)
+	bob := newTestUser(t, a, "bob", model.RoleUser)
+	admin := newTestUser(t, a, "admin", model.RoleAdmin)
+	aliceID := int(ActorFrom(alice).UserID)
+
+	skill, role := "go", model.RoleAdmin
+	if err := a.UpdateUserByID(alice, aliceID, model.UpdateUserInput{Skill: &skill}); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.UpdateUserByID(bob, aliceID, model.UpdateUserInput{Skill: &skill}); !errors.Is(err, ErrPermissionDenied) {
+		t.Fatalf("update another user: error = %v, want ErrPermissionDenied", err)
+	}
+	if err := a.UpdateUserByID(alice, aliceID, model.UpdateUserInput{Role: &role}); !errors.Is(err, ErrPermissionDenied) {
+		t.Fatalf("change own role: error = %v, want ErrPermissionDenied", err)
+	}
+	if err := a.UpdateUserByID(admin, aliceID, model.UpdateUserInput{Role: &role}); err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := a.store.Users.GetByID(context.Background(), uint(aliceID), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Skill != "go" || user.Role != model.RoleAdmin {
+		t.Fatalf("user has skill %q and role %q", user.Skill, user.Role)
+	}
+}
+
+func TestCreatePost(t *testing.T) {
+	a := newTestApp(t)
+	alice := newTestUser(t, a, "alice", model.RoleAuthor)
+
+	for _, title := range []string{"Hello World", "Hello World"} {
+		if err := a.CreatePost(alice, &model.Post{Title: title, Content: "hi", Status: model.PostStatusPublished}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	posts, _, err := a.GetAllPosts(context.Background(), model.PostQuery{PageQuery: model.PageQuery{Sort: model.SortOldest}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*posts) != 2 || (*posts)[0].Slug != "hello-world" || (*posts)[1].Slug != "hello-world-2" {
+		t.Fatalf("got %d posts, want hello-world and hello-world-2", len(*posts))
+	}
+
+	post, err := a.GetPostByID(context.Background(), int((*posts)[1].ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if post.UserRefer != ActorFrom(alice).UserID || post.ContentHTML == "" || post.CommentCount != 0 {
+		t.Fatalf("got post by %d with content %q", post.UserRefer, post.ContentHTML)
+	}
+}
+
+func TestUpdatePostByIDSlug(t *testing.T) {
+	a := newTestApp(t)
+	alice := newTestUser(t, a, "alice", model.RoleAuthor)
+
+	post := &model.Post{Title: "Hello", Content: "hi", Status: model.PostStatusPublished}
+	if err := a.CreatePost(alice, post); err != nil {
+		t.Fatal(err)
+	}
+
+	slug, title := "hi", "Hi"
+	if err := a.UpdatePostByID(alice, int(post.ID), model.UpdatePostInput{Slug: &slug, Title: &title}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, current, err := a.GetPostBySlug(context.Background(), "hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if current != "hi" {
+		t.Fatalf("old slug redirects to %q, want hi", current)
+	}
+
+	got, _, err := a.GetPostBySlug(context.Background(), "hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Title != "Hi" {
+		t.Fatalf("title = %q after update", got.Title)
+	}
+}
+
+func TestLikePostByID(t *testing.T) {
+	a := newTestApp(t)
+	alice := newTestUser(t, a, "alice", model.RoleAuthor)
+	bob := newTestUser(t, a, "bob", model.RoleUser)
+
+	draft := &model.Post{Title: "draft", Status: model.PostStatusDraft, UserRefer: ActorFrom(alice).UserID}
+	published := &model.Post{Title: "published", Status: model.PostStatusPublished, UserRefer: ActorFrom(alice).UserID}
+	for _, post := range []*model.Post{draft, published} {
+		if err := a.store.Posts.Create(context.Background(), post); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := a.LikePostByID(bob, int(draft.ID)); err == nil {
+		t.Fatal("a draft of another author can be liked")
+	}
+	if err := a.LikePostByID(bob, int(published.ID)); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.LikePostByID(bob, int(published.ID)); !errors.Is(err, ErrAlreadyLiked) {
+		t.Fatalf("like twice: error = %v, want ErrAlreadyLiked", err)
+	}
+}
+
+func TestRegisterTakenEmail(t *testing.T) {
+	a := newTestApp(t)
+	if err := a.store.Users.Create(context.Background(), &model.User{Username: "alice", Email: "alice@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+
+	input := model.RegisterInput{Username: "bob", Password: "Secret123", Email: "Alice@Example.com"}
+	_, err := a.Register(context.Background(), input)
+	domainErr := AsError(err)
+	if domainErr == nil || len(domainErr.Fields) != 1 || domainErr.Fields[0].Code != "unique_email" {
+		t.Fatalf("error = %v, want the email to be taken", err)
+	}
+
+	input.Email = "bob@example.com"
+	if _, err := a.Register(context.Background(), input); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,179 @@
+package app
+
+import (
+	"context"
+
+	"github.com/pooulad/blogo/internal/database/model"
+)
+
+const deletedCommentContent = "[deleted]"
+
+func (a *app) GetCommentsByPostID(ctx context.Context, postID int) (*[]*model.CommentResponse, error) {
+	post, err := a.getVisiblePost(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+
+	comments, err := a.store.Comments.ListByPostID(ctx, post.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	threads := buildCommentThreads(comments)
+	return &threads, nil
+}
+
+func (a *app) CreateComment(ctx context.Context, postID int, commentInput model.CommentInput) (*model.Comment, error) {
+	if commentInput.Content == "" {
+		return nil, Invalid("content", "comment content is empty")
+	}
+
+	post, err := a.getVisiblePost(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+
+	if commentInput.ParentID != nil {
+		parent, err := a.store.Comments.GetByID(ctx, *commentInput.ParentID)
+		if err != nil {
+			return nil, NotFound("parent comment")
+		}
+
+		if parent.PostRefer != post.ID {
+			return nil, Invalid("parent_id", "parent comment belongs to another post")
+		}
+	}
+
+	comment := model.Comment{
+		PostRefer: post.ID,
+		UserRefer: ActorFrom(ctx).UserID,
+		ParentID:  commentInput.ParentID,
+		Content:   commentInput.Content,
+	}
+
+	err = a.store.Comments.Create(ctx, &comment)
+	if err != nil {
+		return nil, err
+	}
+
+	return &comment, nil
+}
+
+func (a *app) UpdateCommentByID(ctx context.Context, postID, commentID int, commentInput model.CommentInput) error {
+	if commentInput.Content == "" {
+		return Invalid("content", "comment content is empty")
+	}
+
+	comment, err := a.getPostComment(ctx, postID, commentID)
+	if err != nil {
+		return err
+	}
+
+	// comments can only be edited by their author, moderators may only delete
+	if comment.UserRefer != ActorFrom(ctx).UserID {
+		return ErrPermissionDenied
+	}
+
+	comment.Content = commentInput.Content
+
+	err = a.store.Comments.Update(ctx, comment)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (a *app) DeleteCommentByID(ctx context.Context, postID, commentID int) error {
+	comment, err := a.getPostComment(ctx, postID, commentID)
+	if err != nil {
+		return err
+	}
+
+	if comment.UserRefer != ActorFrom(ctx).UserID && !HasPermission(ActorFrom(ctx).Role, PermCommentsModerate) {
+		return ErrPermissionDenied
+	}
+
+	err = a.store.Comments.Delete(ctx, comment.ID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// getVisiblePost returns the post if the current user can see it.
+func (a *app) getVisiblePost(ctx context.Context, postID int) (*model.Post, error) {
+	post, err := a.store.Posts.GetByID(ctx, uint(postID))
+	if err != nil {
+		return nil, err
+	}
+
+	if !post.IsVisibleTo(ActorFrom(ctx).UserID) {
+		return nil, NotFound("post")
+	}
+
+	return post, nil
+}
+
+// getPostComment returns the comment if it belongs to the given post.
+func (a *app) getPostComment(ctx context.Context, postID, commentID int) (*model.Comment, error) {
+	post, err := a.getVisiblePost(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+
+	comment, err := a.store.Comments.GetByID(ctx, uint(commentID))
+	if err != nil {
+		return nil, err
+	}
+
+	if comment.PostRefer != post.ID {
+		return nil, NotFound("comment")
+	}
+
+	return comment, nil
+}
+
+// buildCommentThreads nests the comments under their parents. the content of
+// deleted comments is hidden, but they stay in place so their replies keep
+// their position in the thread.
+func buildCommentThreads(comments []model.Comment) []*model.CommentResponse {
+	byID := make(map[uint]*model.CommentResponse, len(comments))
+	threads := []*model.CommentResponse{}
+
+	for _, comment := range comments {
+		response := &model.CommentResponse{
+			ID:        comment.ID,
+			PostRefer: comment.PostRefer,
+			UserRefer: comment.UserRefer,
+			ParentID:  comment.ParentID,
+			Content:   comment.Content,
+			CreatedAt: comment.CreatedAt,
+			UpdatedAt: comment.UpdatedAt,
+			Replies:   []*model.CommentResponse{},
+		}
+
+		if comment.DeletedAt.Valid {
+			response.Content = deletedCommentContent
+			response.UserRefer = 0
+			response.Deleted = true
+		}
+
+		byID[comment.ID] = response
+	}
+
+	// comments are ordered by creation, so parents are always seen first
+	for _, comment := range comments {
+		response := byID[comment.ID]
+		if comment.ParentID == nil || byID[*comment.ParentID] == nil {
+			threads = append(threads, response)
+			continue
+		}
+
+		parent := byID[*comment.ParentID]
+		parent.Replies = append(parent.Replies, response)
+	}
+
+	return threads
+}
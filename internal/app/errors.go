@@ -0,0 +1,125 @@
+package app
+
+import (
+	"errors"
+
+	"github.com/pooulad/blogo/internal/database/model"
+	"gorm.io/gorm"
+)
+
+// kinds of domain errors. errors.Is matches an *Error against its kind, and
+// the api maps every kind to one status code.
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrForbidden    = errors.New("forbidden")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrValidation   = errors.New("validation failed")
+	ErrUnavailable  = errors.New("unavailable")
+	// ErrMethodNotAllowed is only returned by the api, for a route which
+	// exists with other methods.
+	ErrMethodNotAllowed = errors.New("method not allowed")
+)
+
+var (
+	// ErrPermissionDenied is returned when the current user's role doesn't
+	// allow the requested operation.
+	ErrPermissionDenied = Forbidden("permission_denied", "permission denied")
+	ErrAlreadyFollowed  = Conflict("already_followed", "user is already followed")
+	ErrSelfFollow       = &Error{Kind: ErrValidation, Code: "self_follow", Message: "users can't follow themselves"}
+	ErrAlreadyLiked     = Conflict("already_liked", "post is already liked")
+	ErrSlugTaken        = Conflict("slug_taken", "slug is already taken")
+	ErrEmailTaken       = Validation(FieldError{Field: "email", Code: "unique_email", Message: "email is already taken"})
+)
+
+// Error is a domain error. Code is stable so clients can rely on it, Message
+// is meant for humans and may change.
+type Error struct {
+	Kind    error
+	Code    string
+	Message string
+	// Fields tells which fields of the input are invalid.
+	Fields []FieldError
+	// Err is the cause of the error. it is logged but never shown to clients.
+	Err error
+}
+
+// FieldError is one invalid field of an input.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// NotFound returns the error of a missing resource, such as a post.
+func NotFound(resource string) *Error {
+	return &Error{Kind: ErrNotFound, Code: "not_found", Message: resource + " not found"}
+}
+
+func Conflict(code, message string) *Error {
+	return &Error{Kind: ErrConflict, Code: code, Message: message}
+}
+
+func Forbidden(code, message string) *Error {
+	return &Error{Kind: ErrForbidden, Code: code, Message: message}
+}
+
+func Unauthorized(code, message string) *Error {
+	return &Error{Kind: ErrUnauthorized, Code: code, Message: message}
+}
+
+func Unavailable(code, message string, err error) *Error {
+	return &Error{Kind: ErrUnavailable, Code: code, Message: message, Err: err}
+}
+
+// Invalid returns the validation error of a single field.
+func Invalid(field, message string) *Error {
+	return Validation(FieldError{Field: field, Code: "invalid", Message: message})
+}
+
+// Validation returns the validation error of the given fields. its message is
+// the message of the first field.
+func Validation(fields ...FieldError) *Error {
+	message := ErrValidation.Error()
+	if len(fields) > 0 {
+		message = fields[0].Message
+	}
+
+	return &Error{Kind: ErrValidation, Code: "validation_failed", Message: message, Fields: fields}
+}
+
+// AsError returns err as a domain error. the errors of the model which have a
+// meaning for clients are turned into domain errors too, every other error is
+// reported as nil.
+func AsError(err error) *Error {
+	var domainErr *Error
+	if errors.As(err, &domainErr) {
+		return domainErr
+	}
+
+	var queryErr *model.QueryError
+	switch {
+	case errors.As(err, &queryErr):
+		e := Invalid(queryErr.Field, queryErr.Message)
+		e.Err = err
+		return e
+	case errors.Is(err, model.ErrNotFound), errors.Is(err, gorm.ErrRecordNotFound):
+		return &Error{Kind: ErrNotFound, Code: "not_found", Message: err.Error(), Err: err}
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return &Error{Kind: ErrConflict, Code: "already_exists", Message: "resource already exists", Err: err}
+	}
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/pooulad/blogo/internal/database/model"
+	"gorm.io/gorm"
+)
+
+func TestAsError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantKind error
+		wantCode string
+	}{
+		{"domain error", ErrAlreadyLiked, ErrConflict, "already_liked"},
+		{"wrapped domain error", fmt.Errorf("like: %w", ErrPermissionDenied), ErrForbidden, "permission_denied"},
+		{"model not found", fmt.Errorf("post %w", model.ErrNotFound), ErrNotFound, "not_found"},
+		{"query error", &model.QueryError{Field: "cursor", Message: "cursor is invalid"}, ErrValidation, "validation_failed"},
+		{"duplicated key", gorm.ErrDuplicatedKey, ErrConflict, "already_exists"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := AsError(tt.err)
+			if got == nil {
+				t.Fatalf("AsError(%v) = nil", tt.err)
+			}
+			if got.Kind != tt.wantKind || got.Code != tt.wantCode {
+				t.Errorf("AsError(%v) = %v/%s, want %v/%s", tt.err, got.Kind, got.Code, tt.wantKind, tt.wantCode)
+			}
+			if !errors.Is(got, tt.wantKind) {
+				t.Errorf("errors.Is(%v, %v) = false", got, tt.wantKind)
+			}
+		})
+	}
+
+	if got := AsError(errors.New("connection refused")); got != nil {
+		t.Errorf("AsError of an internal error = %v, want nil", got)
+	}
+}
+
+func TestValidationFields(t *testing.T) {
+	err := Invalid("title", "title is required")
+	if !errors.Is(err, ErrValidation) {
+		t.Fatalf("errors.Is(%v, ErrValidation) = false", err)
+	}
+	if len(err.Fields) != 1 || err.Fields[0].Field != "title" {
+		t.Errorf("fields = %+v, want one for title", err.Fields)
+	}
+	if err.Error() != "title is required" {
+		t.Errorf("message = %q, want the message of the field", err.Error())
+	}
+}
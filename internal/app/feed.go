@@ -0,0 +1,158 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pooulad/blogo/internal/database/model"
+	"github.com/pooulad/blogo/internal/feed"
+)
+
+const (
+	feedTitle = "blogo"
+	feedSize  = 50
+)
+
+func (a *app) GetHomeFeed(ctx context.Context, query model.PageQuery) (*[]model.PostResponse, *model.Page, error) {
+	userID := ActorFrom(ctx).UserID
+
+	posts, page, err := a.store.Posts.HomeFeed(ctx, userID, query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	postIDs := make([]uint, 0, len(posts))
+	for _, post := range posts {
+		postIDs = append(postIDs, post.ID)
+	}
+
+	likeStats, err := a.store.Posts.LikeStats(ctx, userID, postIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	commentCounts, err := a.store.Comments.CountByPostIDs(ctx, postIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	response := make([]model.PostResponse, 0, len(posts))
+	for _, post := range posts {
+		ensurePostRendered(&post)
+
+		response = append(response, model.PostResponse{
+			ID:           post.ID,
+			Title:        post.Title,
+			Slug:         post.Slug,
+			Content:      post.Content,
+			Format:       post.Format,
+			ContentHTML:  post.ContentHTML,
+			Status:       post.Status,
+			PublishedAt:  post.PublishedAt,
+			UserRefer:    post.UserRefer,
+			Liked:        likeStats[post.ID].Liked,
+			LikedCount:   likeStats[post.ID].LikedCount,
+			CommentCount: commentCounts[post.ID],
+			Tags:         post.Tags,
+			Categories:   post.Categories,
+		})
+	}
+
+	return &response, page, nil
+}
+
+// GetPostsFeed builds the public feed of the latest published posts. links in
+// the feed are absolute, so they are built on the public url of the config.
+func (a *app) GetPostsFeed(ctx context.Context, query model.FeedQuery) (*feed.Feed, error) {
+	baseURL := a.config.PublicUrl
+	f := feed.Feed{
+		Title:       feedTitle,
+		Description: "Latest posts on blogo",
+		Link:        baseURL + "/api/v1/posts",
+	}
+
+	if query.AuthorID != 0 {
+		usernames, err := a.store.Users.Usernames(ctx, []uint{query.AuthorID})
+		if err != nil {
+			return nil, err
+		}
+
+		username, ok := usernames[query.AuthorID]
+		if !ok {
+			return nil, NotFound("user")
+		}
+
+		f.Title = fmt.Sprintf("%s - posts by %s", feedTitle, username)
+		f.Description = fmt.Sprintf("Latest posts by %s on blogo", username)
+		f.Link = fmt.Sprintf("%s/api/v1/posts?author_id=%d", baseURL, query.AuthorID)
+	}
+
+	if query.Tag != "" {
+		slug, err := a.store.Tags.ResolveSlug(ctx, query.Tag)
+		if err != nil {
+			return nil, err
+		}
+
+		tag, err := a.store.Tags.GetBySlug(ctx, slug)
+		if err != nil {
+			return nil, err
+		}
+		query.Tag = tag.Slug
+
+		f.Title = fmt.Sprintf("%s - posts tagged %s", feedTitle, tag.Name)
+		f.Description = fmt.Sprintf("Latest posts tagged %s on blogo", tag.Name)
+		f.Link = fmt.Sprintf("%s/api/v1/tags/%s/posts", baseURL, tag.Slug)
+	}
+
+	posts, err := a.store.Posts.Feed(ctx, query, feedSize)
+	if err != nil {
+		return nil, err
+	}
+
+	authorIDs := make([]uint, 0, len(posts))
+	for _, post := range posts {
+		authorIDs = append(authorIDs, post.UserRefer)
+	}
+
+	usernames, err := a.store.Users.Usernames(ctx, authorIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, post := range posts {
+		ensurePostRendered(&post)
+
+		published := post.CreatedAt
+		if post.PublishedAt != nil {
+			published = *post.PublishedAt
+		}
+
+		tags := make([]string, 0, len(post.Tags))
+		for _, tag := range post.Tags {
+			tags = append(tags, tag.Name)
+		}
+
+		f.Items = append(f.Items, feed.Item{
+			ID:        fmt.Sprintf("%s/api/v1/posts/get/%d", baseURL, post.ID),
+			Title:     post.Title,
+			Link:      fmt.Sprintf("%s/api/v1/posts/by-slug/%s", baseURL, post.Slug),
+			Content:   post.ContentHTML,
+			Author:    usernames[post.UserRefer],
+			Tags:      tags,
+			Published: published,
+			Updated:   post.UpdatedAt,
+		})
+
+		if post.UpdatedAt.After(f.Updated) {
+			f.Updated = post.UpdatedAt
+		}
+	}
+
+	// an empty feed has never changed
+	if f.Updated.IsZero() {
+		f.Updated = time.Unix(0, 0)
+	}
+
+	return &f, nil
+}
@@ -0,0 +1,74 @@
+package app
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pooulad/blogo/internal/database/model"
+)
+
+func TestGetPostsFeedLinksUsePublicUrl(t *testing.T) {
+	a := newSqliteTestApp(t)
+	a.config.PublicUrl = "https://blog.example.com"
+	alice := newTestUser(t, a, "alice", model.RoleAuthor)
+
+	if err := a.CreatePost(alice, &model.Post{Title: "Hello", Content: "world"}); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := a.GetPostsFeed(context.Background(), model.FeedQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.Items) != 1 {
+		t.Fatalf("feed has %d items, want 1", len(f.Items))
+	}
+
+	for _, link := range []string{f.Link, f.Items[0].ID, f.Items[0].Link} {
+		if !strings.HasPrefix(link, "https://blog.example.com/api/v1/") {
+			t.Errorf("link %q isn't on the public url", link)
+		}
+	}
+}
+
+func TestGetHomeFeedOrdersByPublishedAt(t *testing.T) {
+	a := newSqliteTestApp(t)
+	alice := newTestUser(t, a, "alice", model.RoleAuthor)
+	bob := newTestUser(t, a, "bob", model.RoleUser)
+	if err := a.FollowUserByID(bob, int(ActorFrom(alice).UserID)); err != nil {
+		t.Fatal(err)
+	}
+
+	// the backdated post is created last but was published first
+	lastWeek := time.Now().Add(-7 * 24 * time.Hour)
+	for _, post := range []*model.Post{
+		{Title: "New", Content: "published now"},
+		{Title: "Old", Content: "published last week", PublishedAt: &lastWeek},
+	} {
+		if err := a.CreatePost(alice, post); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var titles []string
+	query := model.PageQuery{Limit: 1}
+	for {
+		posts, page, err := a.GetHomeFeed(bob, query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, post := range *posts {
+			titles = append(titles, post.Title)
+		}
+		if !page.HasMore {
+			break
+		}
+		query.Cursor = page.NextCursor
+	}
+
+	if got := strings.Join(titles, ","); got != "New,Old" {
+		t.Errorf("home feed = %s, want New,Old", got)
+	}
+}
@@ -0,0 +1,29 @@
+package app
+
+import (
+	"context"
+)
+
+// Ready reports whether the app can serve requests. the database has to
+// answer a ping and its schema has to be migrated.
+func (a *app) Ready(ctx context.Context) error {
+	if err := a.db.Ping(ctx); err != nil {
+		return Unavailable("not_ready", "database is not reachable", err)
+	}
+
+	// a migration rolled back while the server runs makes it unready too
+	migrated, err := a.db.Migrated(ctx)
+	if err != nil {
+		return Unavailable("not_ready", "database migrations can't be read", err)
+	}
+	if !migrated {
+		return Unavailable("not_ready", "database migrations are not done", nil)
+	}
+
+	return nil
+}
+
+// Close releases the resources of the app like the database pool.
+func (a *app) Close() error {
+	return a.db.Close()
+}
@@ -0,0 +1,124 @@
+package app
+
+import (
+	"context"
+
+	"github.com/pooulad/blogo/internal/database/model"
+)
+
+type Permission string
+
+const (
+	PermUsersRead        Permission = "users:read"
+	PermUsersUpdate      Permission = "users:update"
+	PermUsersCreate      Permission = "users:create"
+	PermUsersUpdateAny   Permission = "users:update_any"
+	PermUsersDelete      Permission = "users:delete"
+	PermUsersManage      Permission = "users:manage"
+	PermUsersFollow      Permission = "users:follow"
+	PermPostsRead        Permission = "posts:read"
+	PermPostsCreate      Permission = "posts:create"
+	PermPostsUpdate      Permission = "posts:update"
+	PermPostsDelete      Permission = "posts:delete"
+	PermPostsUpdateAny   Permission = "posts:update_any"
+	PermPostsDeleteAny   Permission = "posts:delete_any"
+	PermPostsLike        Permission = "posts:like"
+	PermPostsTransfer    Permission = "posts:transfer"
+	PermCommentsCreate   Permission = "comments:create"
+	PermCommentsModerate Permission = "comments:moderate"
+	PermTagsManage       Permission = "tags:manage"
+	PermCategoriesManage Permission = "categories:manage"
+	PermSessionsManage   Permission = "sessions:manage"
+	PermTrashManage      Permission = "trash:manage"
+)
+
+// anonymousPermissions are granted to requests without a token, so visitors
+// can read the blog without an account.
+var anonymousPermissions = []Permission{
+	PermUsersRead,
+	PermPostsRead,
+}
+
+// userPermissions are granted to every role, including plain users. like
+// before roles were enforced, users write posts and manage their own ones,
+// canManagePost keeps them away from the posts of others.
+var userPermissions = []Permission{
+	PermUsersRead,
+	PermUsersUpdate,
+	PermUsersFollow,
+	PermPostsRead,
+	PermPostsCreate,
+	PermPostsUpdate,
+	PermPostsDelete,
+	PermPostsLike,
+	PermCommentsCreate,
+	PermSessionsManage,
+}
+
+// authorPermissions are the same as userPermissions for now, the role marks
+// the accounts which write for the blog.
+var authorPermissions = userPermissions
+
+var editorPermissions = append([]Permission{
+	PermPostsUpdateAny,
+	PermPostsDeleteAny,
+	PermCategoriesManage,
+	PermCommentsModerate,
+}, authorPermissions...)
+
+var adminPermissions = append([]Permission{
+	PermUsersCreate,
+	PermUsersUpdateAny,
+	PermUsersDelete,
+	PermUsersManage,
+	PermPostsTransfer,
+	PermTagsManage,
+	PermTrashManage,
+}, editorPermissions...)
+
+var rolePermissions = map[string][]Permission{
+	model.RoleUser:   userPermissions,
+	model.RoleAuthor: authorPermissions,
+	model.RoleEditor: editorPermissions,
+	model.RoleAdmin:  adminPermissions,
+}
+
+// HasPermission reports whether the role is granted the permission.
+// unknown roles have no permissions at all.
+func HasPermission(role string, permission Permission) bool {
+	for _, p := range rolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasAnonymousPermission reports whether requests without a token are granted
+// the permission.
+func HasAnonymousPermission(permission Permission) bool {
+	for _, p := range anonymousPermissions {
+		if p == permission {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsValidRole reports whether the role is one of the known roles.
+func IsValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// canManagePost reports whether the current user may change the post. authors
+// may change their own posts, anyone else needs the override permission.
+func canManagePost(ctx context.Context, post *model.Post, override Permission) bool {
+	if post.UserRefer == ActorFrom(ctx).UserID {
+		return true
+	}
+
+	return HasPermission(ActorFrom(ctx).Role, override)
+}
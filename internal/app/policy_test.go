@@ -0,0 +1,41 @@
+package app
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/pooulad/blogo/internal/database/model"
+)
+
+func TestUsersManageOwnPosts(t *testing.T) {
+	for _, permission := range []Permission{PermPostsCreate, PermPostsUpdate, PermPostsDelete} {
+		if !HasPermission(model.RoleUser, permission) {
+			t.Errorf("users lack %s", permission)
+		}
+	}
+
+	a := newSqliteTestApp(t)
+	alice := newTestUser(t, a, "alice", model.RoleUser)
+	bob := newTestUser(t, a, "bob", model.RoleUser)
+
+	post := &model.Post{Title: "Mine", Content: "written by a plain user"}
+	if err := a.CreatePost(alice, post); err != nil {
+		t.Fatal(err)
+	}
+
+	title := "Not yours"
+	if err := a.UpdatePostByID(bob, int(post.ID), model.UpdatePostInput{Title: &title}); !errors.Is(err, ErrPermissionDenied) {
+		t.Fatalf("update a post of another user: error = %v, want ErrPermissionDenied", err)
+	}
+	if err := a.DeletePostByID(bob, int(post.ID)); !errors.Is(err, ErrPermissionDenied) {
+		t.Fatalf("delete a post of another user: error = %v, want ErrPermissionDenied", err)
+	}
+
+	title = "Still mine"
+	if err := a.UpdatePostByID(alice, int(post.ID), model.UpdatePostInput{Title: &title}); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.DeletePostByID(alice, int(post.ID)); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,31 @@
+package app
+
+import (
+	"github.com/pooulad/blogo/internal/database/model"
+	"github.com/pooulad/blogo/internal/render"
+)
+
+// renderPostContent renders the source of the post into sanitized HTML.
+func renderPostContent(post *model.Post) error {
+	contentHTML, err := render.Render(post.Format, post.Content)
+	if err != nil {
+		return Invalid("format", err.Error())
+	}
+
+	post.ContentHTML = contentHTML
+	return nil
+}
+
+// ensurePostRendered renders posts created before content_html existed. the
+// result isn't stored, it is written on the next update of the post.
+func ensurePostRendered(post *model.Post) {
+	if post.ContentHTML != "" || post.Content == "" {
+		return
+	}
+
+	if post.Format == "" {
+		post.Format = render.FormatMarkdown
+	}
+
+	_ = renderPostContent(post)
+}
@@ -0,0 +1,120 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/pooulad/blogo/internal/database/model"
+)
+
+func (a *app) GetPostRevisions(ctx context.Context, postID int, query model.PageQuery) (*[]model.PostRevision, *model.Page, error) {
+	if _, err := a.getManagedPost(ctx, postID); err != nil {
+		return nil, nil, err
+	}
+
+	revisions, page, err := a.store.Posts.Revisions(ctx, uint(postID), query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &revisions, page, nil
+}
+
+func (a *app) DiffPostRevisions(ctx context.Context, postID int, query model.RevisionDiffQuery) (*model.RevisionDiff, error) {
+	if _, err := a.getManagedPost(ctx, postID); err != nil {
+		return nil, err
+	}
+
+	if query.From == 0 || query.To == 0 {
+		return nil, Validation(
+			FieldError{Field: "from", Code: "required", Message: "from and to revisions are required"},
+			FieldError{Field: "to", Code: "required", Message: "from and to revisions are required"},
+		)
+	}
+
+	from, err := a.store.Posts.Revision(ctx, uint(postID), query.From)
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := a.store.Posts.Revision(ctx, uint(postID), query.To)
+	if err != nil {
+		return nil, err
+	}
+
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(revisionText(from)),
+		B:        difflib.SplitLines(revisionText(to)),
+		FromFile: fmt.Sprintf("revision %d", from.Number),
+		FromDate: from.CreatedAt.Format(time.RFC3339),
+		ToFile:   fmt.Sprintf("revision %d", to.Number),
+		ToDate:   to.CreatedAt.Format(time.RFC3339),
+		Context:  3,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.RevisionDiff{
+		From: from.Number,
+		To:   to.Number,
+		Diff: diff,
+	}, nil
+}
+
+// RestorePostRevision copies an old revision back into the post. history is
+// never rewritten, the restored content is saved as a new revision.
+func (a *app) RestorePostRevision(ctx context.Context, postID int, number int) error {
+	post, err := a.getManagedPost(ctx, postID)
+	if err != nil {
+		return err
+	}
+
+	revision, err := a.store.Posts.Revision(ctx, uint(postID), number)
+	if err != nil {
+		return err
+	}
+
+	post.Title = revision.Title
+	post.Content = revision.Content
+	post.Format = revision.Format
+
+	if err := renderPostContent(post); err != nil {
+		return err
+	}
+
+	err = a.store.Posts.Update(ctx, post, ActorFrom(ctx).UserID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// getManagedPost returns the post if the current user may change it.
+func (a *app) getManagedPost(ctx context.Context, postID int) (*model.Post, error) {
+	post, err := a.store.Posts.GetByID(ctx, uint(postID))
+	if err != nil {
+		return nil, err
+	}
+
+	if !canManagePost(ctx, post, PermPostsUpdateAny) {
+		return nil, ErrPermissionDenied
+	}
+
+	return post, nil
+}
+
+// revisionText is the text of a revision that is diffed, the title followed
+// by the content.
+func revisionText(revision *model.PostRevision) string {
+	text := revision.Title + "\n\n" + revision.Content
+	if !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+
+	return text
+}
@@ -0,0 +1,57 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/pooulad/blogo/internal/database/model"
+)
+
+// RunScheduler publishes scheduled posts once their publish time has come.
+// it blocks until ctx is done, so callers run it in its own goroutine.
+func (a *app) RunScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		count, err := a.store.Posts.PublishScheduled(ctx, time.Now())
+		if err != nil {
+			slog.ErrorContext(ctx, "publish scheduled posts failed", slog.Any("error", err))
+		} else if count > 0 {
+			slog.InfoContext(ctx, "published scheduled posts", slog.Int64("count", count))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// setPostStatus validates the status change of a post and fills in the
+// publish time. posts without a status are published right away.
+func setPostStatus(post *model.Post, status string, publishedAt *time.Time) error {
+	now := time.Now()
+
+	switch status {
+	case "", model.PostStatusPublished:
+		post.Status = model.PostStatusPublished
+		if publishedAt == nil || publishedAt.After(now) {
+			publishedAt = &now
+		}
+	case model.PostStatusScheduled:
+		if publishedAt == nil || !publishedAt.After(now) {
+			return Invalid("published_at", "scheduled posts need a published_at in the future")
+		}
+		post.Status = model.PostStatusScheduled
+	case model.PostStatusDraft, model.PostStatusArchived:
+		post.Status = status
+	default:
+		return Invalid("status", "status is invalid")
+	}
+
+	post.PublishedAt = publishedAt
+	return nil
+}
@@ -0,0 +1,33 @@
+package app
+
+import (
+	"context"
+
+	"github.com/pooulad/blogo/internal/database/model"
+)
+
+func (a *app) SearchPosts(ctx context.Context, query model.SearchQuery) (*[]model.PostSearchResult, *model.Page, error) {
+	if model.PrefixTSQuery(query.Q) == "" {
+		return nil, nil, Invalid("q", "search query is empty")
+	}
+
+	posts, page, err := a.store.Posts.Search(ctx, ActorFrom(ctx).UserID, query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &posts, page, nil
+}
+
+func (a *app) SearchUsers(ctx context.Context, query model.SearchQuery) (*[]model.UserSearchResult, *model.Page, error) {
+	if model.PrefixTSQuery(query.Q) == "" {
+		return nil, nil, Invalid("q", "search query is empty")
+	}
+
+	users, page, err := a.store.Users.Search(ctx, query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &users, page, nil
+}
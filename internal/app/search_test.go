@@ -0,0 +1,64 @@
+package app
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/pooulad/blogo/internal/database/model"
+	"github.com/pooulad/blogo/internal/render"
+)
+
+func TestSearchEscapesContent(t *testing.T) {
+	a := newSqliteTestApp(t)
+	alice := newTestUser(t, a, "alice", model.RoleAuthor)
+
+	post := &model.Post{
+		Title:   `<script>alert(1)</script> hello`,
+		Content: `<p>hello <img src=x onerror=alert(1)> &amp; world</p>`,
+		Format:  render.FormatHTML,
+	}
+	if err := a.CreatePost(alice, post); err != nil {
+		t.Fatal(err)
+	}
+	user := &model.User{Username: "mallory", Skill: `<img src=x onerror=alert(1)> hello`}
+	if err := a.store.Users.Create(context.Background(), user); err != nil {
+		t.Fatal(err)
+	}
+
+	query := model.SearchQuery{Q: "hello", PageQuery: model.PageQuery{Limit: 20}}
+	posts, _, err := a.SearchPosts(context.Background(), query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*posts) != 1 {
+		t.Fatalf("found %d posts, want 1", len(*posts))
+	}
+	result := (*posts)[0]
+	if result.Headline != "&lt;script&gt;alert(1)&lt;/script&gt; hello" {
+		t.Errorf("headline = %q", result.Headline)
+	}
+	if result.Snippet != "hello &amp; world" {
+		t.Errorf("snippet = %q", result.Snippet)
+	}
+
+	users, _, err := a.SearchUsers(context.Background(), query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*users) != 1 || strings.Contains((*users)[0].Snippet, "<img") {
+		t.Errorf("users = %+v, want one with an escaped snippet", *users)
+	}
+}
+
+func TestSearchRejectsNegativeOffset(t *testing.T) {
+	a := newSqliteTestApp(t)
+
+	cursor := base64.RawURLEncoding.EncodeToString([]byte(`{"o":-5}`))
+	query := model.SearchQuery{Q: "hello", PageQuery: model.PageQuery{Cursor: cursor}}
+	if _, _, err := a.SearchPosts(context.Background(), query); !errors.Is(AsError(err), ErrValidation) {
+		t.Fatalf("error = %v, want a validation error", err)
+	}
+}
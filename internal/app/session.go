@@ -0,0 +1,130 @@
+package app
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/pooulad/blogo/internal/database/model"
+)
+
+const (
+	refreshTokenTTL  = time.Hour * 24 * 30
+	refreshTokenSize = 32
+)
+
+func (a *app) CreateSession(ctx context.Context, input model.SessionInput) (*model.Session, string, error) {
+	refreshToken, err := generateRefreshToken()
+	if err != nil {
+		return nil, "", fmt.Errorf("generate refresh token: %w", err)
+	}
+
+	session := model.Session{
+		UserRefer:        input.UserID,
+		RefreshTokenHash: hashRefreshToken(refreshToken),
+		UserAgent:        input.UserAgent,
+		IP:               input.IP,
+		ExpiresAt:        time.Now().Add(refreshTokenTTL),
+	}
+
+	err = a.store.Sessions.Create(ctx, &session)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &session, refreshToken, nil
+}
+
+func (a *app) RefreshSession(ctx context.Context, refreshToken string) (*model.Session, string, error) {
+	if refreshToken == "" {
+		return nil, "", Invalid("refresh_token", "refresh token is empty")
+	}
+
+	tokenHash := hashRefreshToken(refreshToken)
+	session, err := a.store.Sessions.GetByTokenHash(ctx, tokenHash)
+	if err != nil {
+		// a rotated token is being replayed, so the session is most likely
+		// stolen. revoke it to force a new login on every device using it.
+		reused, reuseErr := a.store.Sessions.GetByPreviousTokenHash(ctx, tokenHash)
+		if reuseErr == nil {
+			if err := a.store.Sessions.Revoke(ctx, reused.ID); err != nil {
+				return nil, "", err
+			}
+		}
+		return nil, "", Unauthorized("invalid_refresh_token", "refresh token is invalid")
+	}
+
+	if !session.IsActive() {
+		return nil, "", Unauthorized("session_revoked", "session is expired or revoked")
+	}
+
+	newRefreshToken, err := generateRefreshToken()
+	if err != nil {
+		return nil, "", fmt.Errorf("generate refresh token: %w", err)
+	}
+
+	expiresAt := time.Now().Add(refreshTokenTTL)
+	err = a.store.Sessions.Rotate(ctx, session, hashRefreshToken(newRefreshToken), expiresAt)
+	if errors.Is(err, model.ErrTokenReused) {
+		return nil, "", Unauthorized("invalid_refresh_token", err.Error())
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	session.ExpiresAt = expiresAt
+
+	return session, newRefreshToken, nil
+}
+
+func (a *app) IsSessionActive(ctx context.Context, sessionID uint) (bool, error) {
+	session, err := a.store.Sessions.GetByID(ctx, sessionID)
+	if err != nil {
+		return false, err
+	}
+
+	return session.IsActive(), nil
+}
+
+func (a *app) Logout(ctx context.Context, sessionID uint) error {
+	err := a.store.Sessions.Revoke(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (a *app) LogoutAll(ctx context.Context, sessionID uint) error {
+	session, err := a.store.Sessions.GetByID(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+
+	err = a.store.Sessions.RevokeByUserID(ctx, session.UserRefer)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func generateRefreshToken() (string, error) {
+	b := make([]byte, refreshTokenSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashRefreshToken hashes the token before it touches the database, so a
+// leaked sessions table can't be used to mint new access tokens.
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,88 @@
+package app
+
+import (
+	"context"
+
+	"github.com/pooulad/blogo/internal/database/model"
+	"github.com/pooulad/blogo/utilities"
+)
+
+// GetPostBySlug returns the post with the slug. when the slug is an old one
+// the post isn't returned, only its current slug so the client can be
+// redirected to it.
+func (a *app) GetPostBySlug(ctx context.Context, slug string) (*model.PostResponse, string, error) {
+	slug = utilities.Slugify(slug)
+	if slug == "" {
+		return nil, "", NotFound("post")
+	}
+
+	post, err := a.store.Posts.GetBySlug(ctx, slug)
+	if err == nil {
+		response, err := a.GetPostByID(ctx, int(post.ID))
+		if err != nil {
+			return nil, "", err
+		}
+
+		return response, "", nil
+	}
+
+	post, err = a.store.Posts.GetByOldSlug(ctx, slug)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if !post.IsVisibleTo(ActorFrom(ctx).UserID) {
+		return nil, "", NotFound("post")
+	}
+
+	return nil, post.Slug, nil
+}
+
+// newPostSlug returns the slug of a new post. a slug given by the author must
+// be free, otherwise one is generated from the title.
+func (a *app) newPostSlug(ctx context.Context, postBody *model.Post) (string, error) {
+	if postBody.Slug == "" {
+		return a.store.Posts.UniqueSlug(ctx, postBody.Title, 0)
+	}
+
+	slug := utilities.Slugify(postBody.Slug)
+	if slug == "" {
+		return "", Invalid("slug", "slug is invalid")
+	}
+
+	taken, err := a.store.Posts.IsSlugTaken(ctx, slug, 0)
+	if err != nil {
+		return "", err
+	}
+
+	if taken {
+		return "", ErrSlugTaken
+	}
+
+	return slug, nil
+}
+
+// changePostSlug renames the slug of the post. saving the post keeps the old
+// slug in the history so links to it keep working.
+func (a *app) changePostSlug(ctx context.Context, post *model.Post, value string) error {
+	slug := utilities.Slugify(value)
+	if slug == "" {
+		return Invalid("slug", "slug is invalid")
+	}
+
+	if slug == post.Slug {
+		return nil
+	}
+
+	taken, err := a.store.Posts.IsSlugTaken(ctx, slug, post.ID)
+	if err != nil {
+		return err
+	}
+
+	if taken {
+		return ErrSlugTaken
+	}
+
+	post.Slug = slug
+	return nil
+}
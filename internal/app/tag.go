@@ -0,0 +1,193 @@
+package app
+
+import (
+	"context"
+
+	"github.com/pooulad/blogo/internal/database/model"
+	"github.com/pooulad/blogo/utilities"
+)
+
+func (a *app) AttachTagsToPost(ctx context.Context, postID int, names []string) error {
+	post, err := a.store.Posts.GetByID(ctx, uint(postID))
+	if err != nil {
+		return err
+	}
+
+	if !canManagePost(ctx, post, PermPostsUpdateAny) {
+		return ErrPermissionDenied
+	}
+
+	tags, err := a.store.Tags.FindOrCreate(ctx, names)
+	if err != nil {
+		return err
+	}
+
+	if len(tags) == 0 {
+		return Invalid("tags", "no valid tag given")
+	}
+
+	err = a.store.Tags.Attach(ctx, post.ID, tags)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (a *app) DetachTagFromPost(ctx context.Context, postID int, slug string) error {
+	post, err := a.store.Posts.GetByID(ctx, uint(postID))
+	if err != nil {
+		return err
+	}
+
+	if !canManagePost(ctx, post, PermPostsUpdateAny) {
+		return ErrPermissionDenied
+	}
+
+	slug, err = a.store.Tags.ResolveSlug(ctx, slug)
+	if err != nil {
+		return err
+	}
+
+	tag, err := a.store.Tags.GetBySlug(ctx, slug)
+	if err != nil {
+		return err
+	}
+
+	err = a.store.Tags.Detach(ctx, post.ID, tag.ID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (a *app) GetTagCounts(ctx context.Context) (*[]model.TagCount, error) {
+	counts, err := a.store.Tags.Counts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &counts, nil
+}
+
+func (a *app) MergeTags(ctx context.Context, from, into string) error {
+	if !HasPermission(ActorFrom(ctx).Role, PermTagsManage) {
+		return ErrPermissionDenied
+	}
+
+	fromTag, err := a.store.Tags.GetBySlug(ctx, utilities.Slugify(from))
+	if err != nil {
+		return err
+	}
+
+	intoSlug, err := a.store.Tags.ResolveSlug(ctx, into)
+	if err != nil {
+		return err
+	}
+
+	intoTag, err := a.store.Tags.GetBySlug(ctx, intoSlug)
+	if err != nil {
+		return err
+	}
+
+	if fromTag.ID == intoTag.ID {
+		return Invalid("into", "can't merge a tag into itself")
+	}
+
+	err = a.store.Tags.Merge(ctx, fromTag, intoTag)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (a *app) CreateCategory(ctx context.Context, categoryBody *model.Category) error {
+	if !HasPermission(ActorFrom(ctx).Role, PermCategoriesManage) {
+		return ErrPermissionDenied
+	}
+
+	if categoryBody.Slug == "" {
+		categoryBody.Slug = categoryBody.Name
+	}
+	categoryBody.Slug = utilities.Slugify(categoryBody.Slug)
+
+	if categoryBody.Name == "" || categoryBody.Slug == "" {
+		return Invalid("name", "category name is invalid")
+	}
+
+	if _, err := a.store.Categories.GetBySlug(ctx, categoryBody.Slug); err == nil {
+		return Conflict("category_exists", "category already exist")
+	}
+
+	err := a.store.Categories.Create(ctx, categoryBody)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (a *app) GetCategoryCounts(ctx context.Context) (*[]model.CategoryCount, error) {
+	counts, err := a.store.Categories.Counts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &counts, nil
+}
+
+func (a *app) AttachCategoriesToPost(ctx context.Context, postID int, slugs []string) error {
+	post, err := a.store.Posts.GetByID(ctx, uint(postID))
+	if err != nil {
+		return err
+	}
+
+	if !canManagePost(ctx, post, PermPostsUpdateAny) {
+		return ErrPermissionDenied
+	}
+
+	var categories []model.Category
+	for _, slug := range slugs {
+		category, err := a.store.Categories.GetBySlug(ctx, utilities.Slugify(slug))
+		if err != nil {
+			return err
+		}
+		categories = append(categories, *category)
+	}
+
+	if len(categories) == 0 {
+		return Invalid("categories", "no category given")
+	}
+
+	err = a.store.Categories.Attach(ctx, post.ID, categories)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (a *app) DetachCategoryFromPost(ctx context.Context, postID int, slug string) error {
+	post, err := a.store.Posts.GetByID(ctx, uint(postID))
+	if err != nil {
+		return err
+	}
+
+	if !canManagePost(ctx, post, PermPostsUpdateAny) {
+		return ErrPermissionDenied
+	}
+
+	category, err := a.store.Categories.GetBySlug(ctx, utilities.Slugify(slug))
+	if err != nil {
+		return err
+	}
+
+	err = a.store.Categories.Detach(ctx, post.ID, category.ID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
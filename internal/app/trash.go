@@ -0,0 +1,163 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/pooulad/blogo/internal/database/model"
+	"github.com/pooulad/blogo/internal/database/repository"
+)
+
+func (a *app) GetTrashedPosts(ctx context.Context, query model.PageQuery) (*[]model.Post, *model.Page, error) {
+	if !HasPermission(ActorFrom(ctx).Role, PermTrashManage) {
+		return nil, nil, ErrPermissionDenied
+	}
+
+	posts, page, err := a.store.Trash.Posts(ctx, query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &posts, page, nil
+}
+
+func (a *app) GetTrashedUsers(ctx context.Context, query model.PageQuery) (*[]model.UserResponse, *model.Page, error) {
+	if !HasPermission(ActorFrom(ctx).Role, PermTrashManage) {
+		return nil, nil, ErrPermissionDenied
+	}
+
+	users, page, err := a.store.Trash.Users(ctx, query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &users, page, nil
+}
+
+func (a *app) RestorePostByID(ctx context.Context, postID int) error {
+	if !HasPermission(ActorFrom(ctx).Role, PermTrashManage) {
+		return ErrPermissionDenied
+	}
+
+	post, err := a.store.Trash.GetPost(ctx, uint(postID))
+	if err != nil {
+		return err
+	}
+
+	// a post can't outlive its author, restore the author first
+	if _, err := a.store.Trash.GetUser(ctx, post.UserRefer); err == nil {
+		return Conflict("author_in_trash", "author of the post is in the trash, restore the author first")
+	}
+
+	err = a.store.Trash.RestorePost(ctx, uint(postID))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (a *app) RestoreUserByID(ctx context.Context, userID int) error {
+	if !HasPermission(ActorFrom(ctx).Role, PermTrashManage) {
+		return ErrPermissionDenied
+	}
+
+	user, err := a.store.Trash.GetUser(ctx, uint(userID))
+	if err != nil {
+		return err
+	}
+
+	// the username or email may have been given to another user meanwhile
+	_, err = a.store.Users.GetByUsername(ctx, user.Username)
+	if err == nil {
+		return Conflict("username_taken", "username is taken by another user")
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return err
+	}
+	if user.Email != "" {
+		taken, err := a.store.Users.IsEmailTaken(ctx, user.Email, user.ID)
+		if err != nil {
+			return err
+		}
+		if taken {
+			return Conflict("email_taken", "email is taken by another user")
+		}
+	}
+
+	err = a.store.Trash.RestoreUser(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (a *app) PurgePostByID(ctx context.Context, postID int) error {
+	if !HasPermission(ActorFrom(ctx).Role, PermTrashManage) {
+		return ErrPermissionDenied
+	}
+
+	// only trashed posts can be purged, so nothing is lost by a single request
+	if _, err := a.store.Trash.GetPost(ctx, uint(postID)); err != nil {
+		return err
+	}
+
+	err := a.store.Trash.PurgePost(ctx, uint(postID))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (a *app) PurgeUserByID(ctx context.Context, userID int) error {
+	if !HasPermission(ActorFrom(ctx).Role, PermTrashManage) {
+		return ErrPermissionDenied
+	}
+
+	if _, err := a.store.Trash.GetUser(ctx, uint(userID)); err != nil {
+		return err
+	}
+
+	err := a.store.Trash.PurgeUser(ctx, uint(userID))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RunTrashRetention purges the posts and users which have been in the trash
+// longer than the retention. it blocks until ctx is done, so callers run it
+// in its own goroutine.
+func (a *app) RunTrashRetention(ctx context.Context, interval, retention time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		before := time.Now().Add(-retention)
+
+		count, err := a.store.Trash.PurgeExpiredUsers(ctx, before)
+		if err != nil {
+			slog.ErrorContext(ctx, "purge expired users failed", slog.Any("error", err))
+		} else if count > 0 {
+			slog.InfoContext(ctx, "purged users from the trash", slog.Int64("count", count))
+		}
+
+		count, err = a.store.Trash.PurgeExpiredPosts(ctx, before)
+		if err != nil {
+			slog.ErrorContext(ctx, "purge expired posts failed", slog.Any("error", err))
+		} else if count > 0 {
+			slog.InfoContext(ctx, "purged posts from the trash", slog.Int64("count", count))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/pooulad/blogo/internal/config"
+	"github.com/pooulad/blogo/internal/database"
+	"github.com/pooulad/blogo/internal/database/model"
+)
+
+// newSqliteTestApp returns an app on a fresh in-memory sqlite database, for
+// the features which don't go through the repositories yet.
+func newSqliteTestApp(t *testing.T) *app {
+	t.Helper()
+
+	cfg := &config.Config{DB: config.DB{Driver: config.DriverSqlite, Sqlite: config.Sqlite{Path: ":memory:"}}}
+	store, err := database.Connect(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	return New(store.Repositories, store, cfg)
+}
+
+func TestRestoreUserByIDRestoresItsPosts(t *testing.T) {
+	a := newSqliteTestApp(t)
+	alice := newTestUser(t, a, "alice", model.RoleAuthor)
+	admin := newTestUser(t, a, "admin", model.RoleAdmin)
+	aliceID := int(ActorFrom(alice).UserID)
+
+	trashedBefore := &model.Post{Title: "Trashed before", Content: "gone"}
+	kept := &model.Post{Title: "Kept", Content: "still here"}
+	for _, post := range []*model.Post{trashedBefore, kept} {
+		if err := a.CreatePost(alice, post); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := a.DeletePostByID(alice, int(trashedBefore.ID)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := a.DeleteUserByID(admin, aliceID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.store.Posts.GetByID(context.Background(), kept.ID); err == nil {
+		t.Fatal("post of the deleted user is not in the trash")
+	}
+
+	if err := a.RestoreUserByID(admin, aliceID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.store.Users.GetByID(context.Background(), uint(aliceID), 0); err != nil {
+		t.Fatalf("restored user: %v", err)
+	}
+	if _, err := a.store.Posts.GetByID(context.Background(), kept.ID); err != nil {
+		t.Fatalf("post trashed with the user is not restored: %v", err)
+	}
+	if _, err := a.store.Trash.GetPost(context.Background(), trashedBefore.ID); err != nil {
+		t.Fatalf("post trashed before the user left the trash: %v", err)
+	}
+}
+
+func TestRestoreUserByIDTakenUsername(t *testing.T) {
+	a := newSqliteTestApp(t)
+	admin := newTestUser(t, a, "admin", model.RoleAdmin)
+
+	alice := &model.User{Username: "alice", Email: "alice@example.com"}
+	if err := a.store.Users.Create(context.Background(), alice); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.DeleteUserByID(admin, int(alice.ID)); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.store.Users.Create(context.Background(), &model.User{Username: "alice", Email: "new.alice@example.com"}); err != nil {
+		t.Fatalf("username of a trashed user: %v", err)
+	}
+
+	err := a.RestoreUserByID(admin, int(alice.ID))
+	if domainErr := AsError(err); domainErr == nil || domainErr.Kind != ErrConflict {
+		t.Fatalf("error = %v, want a conflict", err)
+	}
+
+	err = a.store.Users.Create(context.Background(), &model.User{Username: "bob", Email: "New.Alice@example.com"})
+	if domainErr := AsError(err); domainErr == nil || domainErr.Kind != ErrConflict {
+		t.Fatalf("duplicate email: error = %v, want a conflict", err)
+	}
+}
+
+func TestTrashQueriesUseTheContext(t *testing.T) {
+	a := newSqliteTestApp(t)
+	admin := newTestUser(t, a, "admin", model.RoleAdmin)
+
+	ctx, cancel := context.WithCancel(admin)
+	cancel()
+
+	if _, _, err := a.GetTrashedPosts(ctx, model.PageQuery{Limit: 20}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("error = %v, want context.Canceled", err)
+	}
+}
@@ -4,8 +4,10 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
+	"net/url"
 	"os"
 	"strconv"
+	"strings"
 )
 
 func New() (*Config, error) {
@@ -15,6 +17,7 @@ func New() (*Config, error) {
 	var (
 		env        = os.Getenv("ENVIRONMENT")
 		app_url    = os.Getenv("APP_URL")
+		publicUrl  = os.Getenv("PUBLIC_URL")
 		port       = os.Getenv("PORT")
 		dbPort     = os.Getenv("DB_PORT")
 		dbName     = os.Getenv("DB_NAME")
@@ -23,30 +26,47 @@ func New() (*Config, error) {
 		dbPassword = os.Getenv("DB_PASSWORD")
 		dbSslmode  = os.Getenv("DB_SSLMODE")
 		configFile = os.Getenv("CONFIG_FILE")
+		adminUser  = os.Getenv("ADMIN_USERNAME")
+		retention  = os.Getenv("TRASH_RETENTION_DAYS")
+		logLevel   = os.Getenv("LOG_LEVEL")
+		logFormat  = os.Getenv("LOG_FORMAT")
+		grace      = os.Getenv("SHUTDOWN_GRACE_SECONDS")
+		dbMigrate  = os.Getenv("DB_MIGRATE")
+		dbDriver   = os.Getenv("DB_DRIVER")
+		sqlitePath = os.Getenv("DB_SQLITE_PATH")
 	)
 
+	// an unset or invalid value falls back to the default retention
+	retentionDays, _ := strconv.Atoi(retention)
+	graceSeconds, _ := strconv.Atoi(grace)
+
 	// check config from command-line
 	flag.StringVar((*string)(&config.Environment), "env", env, "application environment: Production or Development mode")
 	flag.StringVar(&config.AppUrl, "app_url", app_url, "application url")
 	flag.StringVar(&config.Port, "port", port, "application port")
+	flag.StringVar(&config.PublicUrl, "public_url", publicUrl, "scheme and host clients reach the server at, such as https://blog.example.com")
+	flag.StringVar(&config.AdminUsername, "admin_username", adminUser, "username which gets the admin role on register")
+	flag.IntVar(&config.TrashRetentionDays, "trash_retention_days", retentionDays, "days deleted posts and users stay in the trash")
+	flag.IntVar(&config.ShutdownGraceSeconds, "shutdown_grace_seconds", graceSeconds, "seconds in-flight requests get to finish on shutdown")
+	flag.StringVar(&config.Log.Level, "log_level", logLevel, "log level: debug, info, warn or error")
+	flag.StringVar(&config.Log.Format, "log_format", logFormat, "log format: json or text")
+	flag.StringVar(&config.DB.Driver, "db_driver", dbDriver, "database driver: postgres or sqlite")
+	flag.StringVar(&config.DB.Sqlite.Path, "db_sqlite_path", sqlitePath, "sqlite database file, or :memory:")
 	flag.StringVar(&config.DB.Postgresql.Port, "db_port", dbPort, "database port")
 	flag.StringVar(&config.DB.Postgresql.DbName, "db_name", dbName, "database name")
 	flag.StringVar(&config.DB.Postgresql.Host, "db_host", dbHost, "database host")
 	flag.StringVar(&config.DB.Postgresql.Username, "db_username", dbUsername, "database username")
 	flag.StringVar(&config.DB.Postgresql.Password, "db_password", dbPassword, "database password")
 	flag.StringVar(&config.DB.Postgresql.SslMode, "db_sslmode", dbSslmode, "database sslmode")
+	flag.StringVar(&config.DB.Migrate, "db_migrate", dbMigrate, "auto applies pending migrations on boot, check refuses to boot while migrations are pending")
 	flag.StringVar(&configFile, "cfg", configFile, "confige file")
 
 	flag.Parse()
 
-	fmt.Println(configFile)
-	
 	err := readAppConfig(&config, configFile)
 	if err != nil {
 		return nil, err
 	}
-	
-	fmt.Println(config)
 
 	err = verifyAppConfig(&config)
 	if err != nil {
@@ -91,20 +111,71 @@ func verifyAppConfig(cfg *Config) error {
 		return fmt.Errorf("port must be a number between 0 and 65535")
 	}
 
-	if cfg.DB.Postgresql.Port == "" {
-		return fmt.Errorf("sql port must be specified")
+	if cfg.PublicUrl == "" {
+		cfg.PublicUrl = fmt.Sprintf("http://%s:%s", cfg.AppUrl, cfg.Port)
+	}
+	publicUrl, err := url.Parse(cfg.PublicUrl)
+	if err != nil || (publicUrl.Scheme != "http" && publicUrl.Scheme != "https") || publicUrl.Host == "" {
+		return fmt.Errorf("public url must be an absolute http or https url")
+	}
+	cfg.PublicUrl = strings.TrimSuffix(cfg.PublicUrl, "/")
+
+	if cfg.DB.Driver == "" {
+		cfg.DB.Driver = DriverPostgres
+	}
+	switch cfg.DB.Driver {
+	case DriverPostgres:
+		if cfg.DB.Postgresql.Port == "" {
+			return fmt.Errorf("sql port must be specified")
+		}
+		if cfg.DB.Postgresql.Host == "" {
+			return fmt.Errorf("sql host must be specified")
+		}
+		if cfg.DB.Postgresql.Username == "" {
+			return fmt.Errorf("sql username must be specified")
+		}
+		if cfg.DB.Postgresql.DbName == "" {
+			return fmt.Errorf("sql username must be specified")
+		}
+		if cfg.DB.Postgresql.SslMode == "" {
+			cfg.DB.Postgresql.SslMode = SslMode
+		}
+	case DriverSqlite:
+		if cfg.DB.Sqlite.Path == "" {
+			return fmt.Errorf("sqlite path must be specified")
+		}
+	default:
+		return fmt.Errorf("db driver must be %s or %s", DriverPostgres, DriverSqlite)
+	}
+	if cfg.DB.Migrate == "" {
+		cfg.DB.Migrate = MigrateAuto
+	}
+	if cfg.DB.Migrate != MigrateAuto && cfg.DB.Migrate != MigrateCheck {
+		return fmt.Errorf("db migrate must be %s or %s", MigrateAuto, MigrateCheck)
+	}
+
+	if cfg.TrashRetentionDays < 0 {
+		return fmt.Errorf("trash retention days can't be negative")
 	}
-	if cfg.DB.Postgresql.Host == "" {
-		return fmt.Errorf("sql host must be specified")
+	if cfg.TrashRetentionDays == 0 {
+		cfg.TrashRetentionDays = DefaultTrashRetentionDays
 	}
-	if cfg.DB.Postgresql.Username == "" {
-		return fmt.Errorf("sql username must be specified")
+
+	if cfg.ShutdownGraceSeconds < 0 {
+		return fmt.Errorf("shutdown grace seconds can't be negative")
+	}
+	if cfg.ShutdownGraceSeconds == 0 {
+		cfg.ShutdownGraceSeconds = DefaultShutdownGrace
 	}
-	if cfg.DB.Postgresql.DbName == "" {
-		return fmt.Errorf("sql username must be specified")
+
+	if cfg.Log.Level == "" {
+		cfg.Log.Level = DefaultLogLevel
 	}
-	if cfg.DB.Postgresql.SslMode == "" {
-		cfg.DB.Postgresql.SslMode = SslMode
+	if cfg.Log.Format == "" {
+		cfg.Log.Format = "json"
+		if cfg.Environment == Development {
+			cfg.Log.Format = "text"
+		}
 	}
 
 	return nil
@@ -4,17 +4,58 @@ const (
 	Development environment = "development"
 	Production  environment = "production"
 	SslMode                 = "disable"
+
+	DefaultTrashRetentionDays = 30
+	DefaultLogLevel           = "info"
+	DefaultShutdownGrace      = 15
+
+	// MigrateAuto applies pending migrations on boot, MigrateCheck refuses to
+	// boot while migrations are pending.
+	MigrateAuto  = "auto"
+	MigrateCheck = "check"
+
+	// DriverPostgres is the default database, DriverSqlite runs the server
+	// on a single file or in memory without a database server.
+	DriverPostgres = "postgres"
+	DriverSqlite   = "sqlite"
 )
 
 type Config struct {
 	Environment environment `json:"environment"`
 	AppUrl      string      `json:"app_url"`
 	Port        string      `json:"port"`
-	DB          DB          `json:"db"`
+	// PublicUrl is the scheme and host clients reach the server at, such as
+	// https://blog.example.com. absolute links, like those of feeds, are built
+	// on it and never on the Host header of a request.
+	PublicUrl string `json:"public_url"`
+	// AdminUsername gets the admin role on register. it is the only way to
+	// bootstrap the first admin of a fresh instance.
+	AdminUsername string `json:"admin_username"`
+	// TrashRetentionDays is how long deleted posts and users stay in the
+	// trash before they are purged for good.
+	TrashRetentionDays int `json:"trash_retention_days"`
+	// ShutdownGraceSeconds is how long in-flight requests may take to finish
+	// once the server is asked to stop.
+	ShutdownGraceSeconds int `json:"shutdown_grace_seconds"`
+	Log                  Log `json:"log"`
+	DB                   DB  `json:"db"`
+}
+
+type Log struct {
+	// Level is one of debug, info, warn or error.
+	Level string `json:"level"`
+	// Format is json or text. text is easier to read in development.
+	Format string `json:"format"`
 }
 
 type DB struct {
+	// Driver is postgres or sqlite. only the block of the driver is used.
+	Driver string `json:"driver"`
+	// Migrate is auto or check. with check, migrations are run on their own
+	// with blogo migrate up before the new version is deployed.
+	Migrate    string     `json:"migrate"`
 	Postgresql Postgresql `json:"postgresql"`
+	Sqlite     Sqlite     `json:"sqlite"`
 }
 
 type Postgresql struct {
@@ -26,6 +67,12 @@ type Postgresql struct {
 	SslMode  string `json:"sslmode"`
 }
 
+type Sqlite struct {
+	// Path is the database file, :memory: keeps the database in memory until
+	// the server stops.
+	Path string `json:"path"`
+}
+
 type environment string
 
 // func (e environment) String() string {
@@ -1,44 +1,141 @@
 package database
 
 import (
+	"context"
 	"fmt"
 
+	"github.com/glebarez/sqlite"
 	"github.com/pooulad/blogo/internal/config"
-	"github.com/pooulad/blogo/internal/database/model"
+	"github.com/pooulad/blogo/internal/database/repository"
+	"github.com/pooulad/blogo/internal/metrics"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
 type Store struct {
-	DB    *gorm.DB
-	Model model.Models
+	DB *gorm.DB
+	// the app reads and writes every row through the repositories
+	repository.Repositories
 }
 
+// Connect opens the database and brings its schema up to date. pending
+// migrations are applied unless cfg.DB.Migrate is check, then they make
+// Connect fail.
 func Connect(cfg *config.Config) (*Store, error) {
-	var (
-		host     = cfg.DB.Postgresql.Host
-		username = cfg.DB.Postgresql.Username
-		password = cfg.DB.Postgresql.Password
-		dbname   = cfg.DB.Postgresql.DbName
-		port     = cfg.DB.Postgresql.Port
-		sslmode  = cfg.DB.Postgresql.SslMode
-	)
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=%s", host, username, password, dbname, port, sslmode)
-	fmt.Println("this is dsn:",dsn)
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	db, err := Open(cfg)
 	if err != nil {
 		return nil, err
 	}
 
-	err = db.AutoMigrate(&model.User{}, &model.Post{})
+	ctx := context.Background()
+	if cfg.DB.Migrate == config.MigrateCheck {
+		pending, err := PendingMigrations(ctx, db)
+		if err != nil {
+			return nil, err
+		}
+		if pending > 0 {
+			return nil, fmt.Errorf("%d migrations are pending, run blogo migrate up first", pending)
+		}
+	} else {
+		if _, err := MigrateUp(ctx, db); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Store{
+		DB:           db,
+		Repositories: repository.NewGorm(db),
+	}, nil
+}
+
+// Open connects to the database of cfg.DB.Driver without touching its schema.
+func Open(cfg *config.Config) (*gorm.DB, error) {
+	var dialector gorm.Dialector
+	switch cfg.DB.Driver {
+	case config.DriverSqlite:
+		dialector = sqlite.Open(cfg.DB.Sqlite.Path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)")
+	default:
+		var (
+			host     = cfg.DB.Postgresql.Host
+			username = cfg.DB.Postgresql.Username
+			password = cfg.DB.Postgresql.Password
+			dbname   = cfg.DB.Postgresql.DbName
+			port     = cfg.DB.Postgresql.Port
+			sslmode  = cfg.DB.Postgresql.SslMode
+		)
+		dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=%s", host, username, password, dbname, port, sslmode)
+		dialector = postgres.Open(dsn)
+	}
+
+	return OpenDialector(dialector)
+}
+
+// OpenDialector opens the database of the dialector with the settings every
+// connection of blogo uses, tests included.
+func OpenDialector(dialector gorm.Dialector) (*gorm.DB, error) {
+	// unique violations are reported as gorm.ErrDuplicatedKey on every driver
+	db, err := gorm.Open(dialector, &gorm.Config{TranslateError: true})
 	if err != nil {
 		return nil, err
 	}
 
-	model := model.NewModels()
+	if dialector.Name() == config.DriverSqlite {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return nil, err
+		}
+		// every connection to :memory: opens a database of its own, and sqlite
+		// allows a single writer anyway
+		sqlDB.SetMaxOpenConns(1)
+	}
 
-	return &Store{
-		DB:    db,
-		Model: model,
-	}, nil
+	err = db.Use(metrics.GormPlugin{})
+	if err != nil {
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// Ping checks that the database can be reached.
+func (s *Store) Ping(ctx context.Context) error {
+	sqlDB, err := s.DB.DB()
+	if err != nil {
+		return err
+	}
+
+	return sqlDB.PingContext(ctx)
+}
+
+// Migrated reports whether every migration of this build is applied. it only
+// reads schema_migrations, without the migration lock, so probes can call it
+// often.
+func (s *Store) Migrated(ctx context.Context) (bool, error) {
+	migrations, err := loadMigrations(s.DB)
+	if err != nil {
+		return false, err
+	}
+
+	applied, err := readAppliedMigrations(ctx, s.DB)
+	if err != nil {
+		return false, err
+	}
+
+	for _, m := range migrations {
+		if _, ok := applied[m.Version]; !ok {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// Close closes the connection pool of the database.
+func (s *Store) Close() error {
+	sqlDB, err := s.DB.DB()
+	if err != nil {
+		return err
+	}
+
+	return sqlDB.Close()
 }
@@ -0,0 +1,526 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pooulad/blogo/utilities"
+	"gorm.io/gorm"
+)
+
+// migrationLockKey is the key of the advisory lock held while migrating, so
+// replicas which boot together don't run the same migration twice.
+const migrationLockKey = 4242424242
+
+// MigrationsDir is where migrate create writes new migrations. it has a
+// directory of migrations per dialect, named after the gorm dialector.
+const MigrationsDir = "internal/database/migrations"
+
+//go:embed migrations/*/*.sql
+var migrationFiles embed.FS
+
+// baselineColumns are the columns which posts gained after AutoMigrate had
+// created it, with their type in postgres and in sqlite.
+var baselineColumns = []struct{ name, postgres, sqlite string }{
+	{"slug", "text", "text"},
+	{"format", "text DEFAULT 'markdown'", "text DEFAULT 'markdown'"},
+	{"content_html", "text", "text"},
+	{"status", "text DEFAULT 'published'", "text DEFAULT 'published'"},
+	{"published_at", "timestamptz", "datetime"},
+}
+
+// beforeMigration are the go steps which run in the transaction of a
+// migration before its sql, for what sql can't do in every dialect.
+var beforeMigration = map[int64]func(ctx context.Context, tx *sql.Tx, dialect string) error{
+	// 0001_init indexes columns which databases created by AutoMigrate may
+	// not have yet
+	1: adoptBaseline,
+	// slugs are made by utilities.Slugify, which has no sql counterpart
+	4: backfillPostSlugs,
+}
+
+// migration files are named <version>_<name>.up.sql and <version>_<name>.down.sql
+var migrationFile = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+type MigrationStatus struct {
+	Version   int64
+	Name      string
+	AppliedAt *time.Time
+}
+
+func (s MigrationStatus) Applied() bool {
+	return s.AppliedAt != nil
+}
+
+// loadMigrations reads the embedded migrations of the dialect of db sorted
+// by version.
+func loadMigrations(db *gorm.DB) ([]Migration, error) {
+	dir := "migrations/" + db.Dialector.Name()
+	files, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for %s", db.Dialector.Name())
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, file := range files {
+		match := migrationFile.FindStringSubmatch(file.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %s", file.Name())
+		}
+
+		version, _ := strconv.ParseInt(match[1], 10, 64)
+		content, err := migrationFiles.ReadFile(dir + "/" + file.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, m.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// withMigrationLock runs fn on a single connection which holds the migration
+// lock. the schema_migrations table is created first if it's missing.
+// sqlite has no advisory locks, its writes are serialized by the file lock.
+func withMigrationLock(ctx context.Context, db *gorm.DB, fn func(conn *sql.Conn) error) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	appliedAtType := "datetime"
+	if db.Dialector.Name() == "postgres" {
+		appliedAtType = "timestamptz"
+
+		// advisory locks belong to the session, so lock and unlock have to run
+		// on the same connection
+		if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockKey); err != nil {
+			return fmt.Errorf("lock migrations: %w", err)
+		}
+		defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockKey)
+	}
+
+	_, err = conn.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version bigint PRIMARY KEY,
+		name text NOT NULL,
+		applied_at %s NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`, appliedAtType))
+	if err != nil {
+		return err
+	}
+
+	return fn(conn)
+}
+
+// appliedMigrations returns the applied migrations by version.
+func appliedMigrations(ctx context.Context, conn *sql.Conn) (map[int64]MigrationStatus, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, name, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int64]MigrationStatus{}
+	for rows.Next() {
+		var status MigrationStatus
+		var appliedAt time.Time
+		if err := rows.Scan(&status.Version, &status.Name, &appliedAt); err != nil {
+			return nil, err
+		}
+		status.AppliedAt = &appliedAt
+		applied[status.Version] = status
+	}
+
+	return applied, rows.Err()
+}
+
+// readAppliedMigrations returns the applied migrations by version without
+// writing to the database or waiting for the migration lock. a database
+// without schema_migrations has none applied.
+func readAppliedMigrations(ctx context.Context, db *gorm.DB) (map[int64]MigrationStatus, error) {
+	db = db.WithContext(ctx)
+	if !db.Migrator().HasTable("schema_migrations") {
+		return map[int64]MigrationStatus{}, nil
+	}
+
+	rows, err := db.Raw("SELECT version, name, applied_at FROM schema_migrations").Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int64]MigrationStatus{}
+	for rows.Next() {
+		var status MigrationStatus
+		var appliedAt time.Time
+		if err := rows.Scan(&status.Version, &status.Name, &appliedAt); err != nil {
+			return nil, err
+		}
+		status.AppliedAt = &appliedAt
+		applied[status.Version] = status
+	}
+
+	return applied, rows.Err()
+}
+
+// adoptBaseline adds the missing baselineColumns to the posts table of a
+// database created before migrations. fresh databases have no posts table
+// yet, the init migration creates it with every column.
+func adoptBaseline(ctx context.Context, tx *sql.Tx, dialect string) error {
+	query := "SELECT name FROM pragma_table_info('posts')"
+	if dialect == "postgres" {
+		query = "SELECT column_name FROM information_schema.columns WHERE table_schema = current_schema() AND table_name = 'posts'"
+	}
+
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	columns := map[string]bool{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		columns[name] = true
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(columns) == 0 {
+		return nil
+	}
+
+	for _, column := range baselineColumns {
+		if columns[column.name] {
+			continue
+		}
+
+		definition := column.sqlite
+		if dialect == "postgres" {
+			definition = column.postgres
+		}
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("ALTER TABLE posts ADD COLUMN %s %s", column.name, definition)); err != nil {
+			return fmt.Errorf("adopt column posts.%s: %w", column.name, err)
+		}
+	}
+
+	return nil
+}
+
+// backfillPostSlugs gives a slug to every post created before posts had
+// slugs. like new posts, they get the slug of their title with a numeric
+// suffix when another post has it or had it before.
+func backfillPostSlugs(ctx context.Context, tx *sql.Tx, dialect string) error {
+	taken := map[string]bool{}
+	rows, err := tx.QueryContext(ctx, "SELECT slug FROM posts WHERE slug <> '' UNION SELECT slug FROM post_slugs")
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var slug string
+		if err := rows.Scan(&slug); err != nil {
+			rows.Close()
+			return err
+		}
+		taken[slug] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	type post struct {
+		id    int64
+		title string
+	}
+	var posts []post
+	rows, err = tx.QueryContext(ctx, "SELECT id, coalesce(title, '') FROM posts WHERE slug = '' OR slug IS NULL ORDER BY id")
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var p post
+		if err := rows.Scan(&p.id, &p.title); err != nil {
+			rows.Close()
+			return err
+		}
+		posts = append(posts, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, p := range posts {
+		base := utilities.Slugify(p.title)
+		if base == "" {
+			base = "post"
+		}
+
+		slug := base
+		for i := 2; taken[slug]; i++ {
+			slug = fmt.Sprintf("%s-%d", base, i)
+		}
+		taken[slug] = true
+
+		if _, err := tx.ExecContext(ctx, "UPDATE posts SET slug = $1 WHERE id = $2", slug, p.id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// runMigration runs the sql of a migration and records it in one transaction,
+// so a failed migration leaves nothing behind.
+func runMigration(ctx context.Context, conn *sql.Conn, dialect string, m Migration, up bool) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if before, ok := beforeMigration[m.Version]; ok && up {
+		if err := before(ctx, tx, dialect); err != nil {
+			return fmt.Errorf("migration %d_%s: %w", m.Version, m.Name, err)
+		}
+	}
+
+	statement, record := m.Up, "INSERT INTO schema_migrations (version, name) VALUES ($1, $2)"
+	if !up {
+		statement, record = m.Down, "DELETE FROM schema_migrations WHERE version = $1 AND name = $2"
+	}
+
+	if _, err := tx.ExecContext(ctx, statement); err != nil {
+		return fmt.Errorf("migration %d_%s: %w", m.Version, m.Name, err)
+	}
+	if _, err := tx.ExecContext(ctx, record, m.Version, m.Name); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// MigrateUp applies every pending migration and returns how many were applied.
+func MigrateUp(ctx context.Context, db *gorm.DB) (int, error) {
+	migrations, err := loadMigrations(db)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	err = withMigrationLock(ctx, db, func(conn *sql.Conn) error {
+		applied, err := appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range migrations {
+			if _, ok := applied[m.Version]; ok {
+				continue
+			}
+
+			if err := runMigration(ctx, conn, db.Dialector.Name(), m, true); err != nil {
+				return err
+			}
+			slog.InfoContext(ctx, "applied migration", slog.Int64("version", m.Version), slog.String("name", m.Name))
+			count++
+		}
+
+		return nil
+	})
+
+	return count, err
+}
+
+// MigrateDown rolls back the last steps applied migrations and returns how
+// many were rolled back.
+func MigrateDown(ctx context.Context, db *gorm.DB, steps int) (int, error) {
+	migrations, err := loadMigrations(db)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	err = withMigrationLock(ctx, db, func(conn *sql.Conn) error {
+		applied, err := appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(migrations) - 1; i >= 0 && count < steps; i-- {
+			m := migrations[i]
+			if _, ok := applied[m.Version]; !ok {
+				continue
+			}
+			if m.Down == "" {
+				return fmt.Errorf("migration %d_%s can't be rolled back, it has no down file", m.Version, m.Name)
+			}
+
+			if err := runMigration(ctx, conn, db.Dialector.Name(), m, false); err != nil {
+				return err
+			}
+			slog.InfoContext(ctx, "rolled back migration", slog.Int64("version", m.Version), slog.String("name", m.Name))
+			count++
+		}
+
+		return nil
+	})
+
+	return count, err
+}
+
+// MigrationsStatus lists every known migration and when it was applied.
+// applied migrations which this build doesn't know about are listed too. it
+// only reads the database, so it works on databases it may not change.
+func MigrationsStatus(ctx context.Context, db *gorm.DB) ([]MigrationStatus, error) {
+	migrations, err := loadMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := readAppliedMigrations(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []MigrationStatus
+	for _, m := range migrations {
+		status := MigrationStatus{Version: m.Version, Name: m.Name}
+		if a, ok := applied[m.Version]; ok {
+			status.AppliedAt = a.AppliedAt
+			delete(applied, m.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	for _, a := range applied {
+		statuses = append(statuses, a)
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+
+	return statuses, nil
+}
+
+// PendingMigrations returns how many migrations are not applied yet.
+func PendingMigrations(ctx context.Context, db *gorm.DB) (int, error) {
+	statuses, err := MigrationsStatus(ctx, db)
+	if err != nil {
+		return 0, err
+	}
+
+	pending := 0
+	for _, status := range statuses {
+		if !status.Applied() {
+			pending++
+		}
+	}
+
+	return pending, nil
+}
+
+// CreateMigration writes blank up and down files for the next version to
+// every dialect directory of dir and returns their paths, so the versions of
+// the dialects stay in step.
+func CreateMigration(dir, name string) ([]string, error) {
+	name = strings.Trim(regexp.MustCompile(`[^a-z0-9]+`).ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if name == "" {
+		return nil, fmt.Errorf("migration name is required")
+	}
+
+	dialects, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var last int64
+	var dialectDirs []string
+	for _, dialect := range dialects {
+		if !dialect.IsDir() {
+			continue
+		}
+		dialectDir := filepath.Join(dir, dialect.Name())
+		dialectDirs = append(dialectDirs, dialectDir)
+
+		files, err := os.ReadDir(dialectDir)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			if match := migrationFile.FindStringSubmatch(file.Name()); match != nil {
+				version, _ := strconv.ParseInt(match[1], 10, 64)
+				last = max(last, version)
+			}
+		}
+	}
+	if len(dialectDirs) == 0 {
+		return nil, fmt.Errorf("%s has no dialect directories", dir)
+	}
+
+	base := fmt.Sprintf("%04d_%s", last+1, name)
+	var paths []string
+	for _, dialectDir := range dialectDirs {
+		for _, path := range []string{filepath.Join(dialectDir, base+".up.sql"), filepath.Join(dialectDir, base+".down.sql")} {
+			if err := os.WriteFile(path, []byte("-- "+base+"\n"), 0o644); err != nil {
+				return nil, err
+			}
+			paths = append(paths, path)
+		}
+	}
+
+	return paths, nil
+}
@@ -0,0 +1,159 @@
+package database_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pooulad/blogo/internal/config"
+	"github.com/pooulad/blogo/internal/database"
+	"github.com/pooulad/blogo/internal/database/model"
+)
+
+// baselineSchema is the schema which AutoMigrate created for the users and
+// posts of the baseline models, before migrations existed.
+const baselineSchema = `
+CREATE TABLE users (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	created_at datetime,
+	updated_at datetime,
+	deleted_at datetime,
+	first_name text,
+	last_name text,
+	username text,
+	password text,
+	email text,
+	role text,
+	active numeric,
+	skill text,
+	last_visited datetime
+);
+CREATE INDEX idx_users_deleted_at ON users (deleted_at);
+CREATE TABLE user_follows (
+	followed_id integer,
+	follower_id integer,
+	PRIMARY KEY (followed_id, follower_id),
+	CONSTRAINT fk_user_follows_user FOREIGN KEY (followed_id) REFERENCES users (id),
+	CONSTRAINT fk_user_follows_followers FOREIGN KEY (follower_id) REFERENCES users (id)
+);
+CREATE TABLE posts (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	created_at datetime,
+	updated_at datetime,
+	deleted_at datetime,
+	title text,
+	content text,
+	user_refer integer,
+	CONSTRAINT fk_users_posts FOREIGN KEY (user_refer) REFERENCES users (id)
+);
+CREATE INDEX idx_posts_deleted_at ON posts (deleted_at);
+CREATE TABLE likes (
+	user_id integer,
+	post_id integer,
+	PRIMARY KEY (user_id, post_id),
+	CONSTRAINT fk_likes_user FOREIGN KEY (user_id) REFERENCES users (id),
+	CONSTRAINT fk_likes_post FOREIGN KEY (post_id) REFERENCES posts (id)
+);
+`
+
+// TestConnectAdoptsBaseline migrates a database created by AutoMigrate before
+// migrations existed and checks that its posts are kept and readable.
+func TestConnectAdoptsBaseline(t *testing.T) {
+	cfg := &config.Config{DB: config.DB{Driver: config.DriverSqlite, Sqlite: config.Sqlite{Path: filepath.Join(t.TempDir(), "blogo.db")}}}
+
+	db, err := database.Open(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec(baselineSchema).Error; err != nil {
+		t.Fatal(err)
+	}
+	createdAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	for _, statement := range []string{
+		"INSERT INTO users (created_at, username, email, role) VALUES (?, 'alice', 'alice@example.com', 'user')",
+		"INSERT INTO posts (created_at, title, content, user_refer) VALUES (?, 'Old post', 'written before migrations', 1)",
+	} {
+		if err := db.Exec(statement, createdAt).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		sqlDB.Close()
+	}
+
+	store, err := database.Connect(cfg)
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer store.Close()
+
+	var post model.Post
+	if err := store.DB.First(&post, 1).Error; err != nil {
+		t.Fatal(err)
+	}
+	if post.Title != "Old post" || post.Status != model.PostStatusPublished || post.Format != "markdown" {
+		t.Errorf("post = %q/%q/%q, want the old post published as markdown", post.Title, post.Status, post.Format)
+	}
+	if post.PublishedAt == nil || !post.PublishedAt.Equal(createdAt) {
+		t.Errorf("published_at = %v, want %v", post.PublishedAt, createdAt)
+	}
+	if post.Slug != "old-post" {
+		t.Errorf("slug = %q, want old-post", post.Slug)
+	}
+
+	pending, err := database.PendingMigrations(context.Background(), store.DB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pending != 0 {
+		t.Errorf("pending migrations = %d, want 0", pending)
+	}
+}
+
+func TestStoreMigrated(t *testing.T) {
+	cfg := &config.Config{DB: config.DB{Driver: config.DriverSqlite, Sqlite: config.Sqlite{Path: ":memory:"}}}
+	store, err := database.Connect(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	if migrated, err := store.Migrated(ctx); err != nil || !migrated {
+		t.Fatalf("Migrated() = %v, %v after Connect, want true", migrated, err)
+	}
+
+	if _, err := database.MigrateDown(ctx, store.DB, 1); err != nil {
+		t.Fatal(err)
+	}
+	if migrated, err := store.Migrated(ctx); err != nil || migrated {
+		t.Fatalf("Migrated() = %v, %v after a rollback, want false", migrated, err)
+	}
+}
+
+func TestMigrateCheckOnlyReads(t *testing.T) {
+	cfg := &config.Config{DB: config.DB{Driver: config.DriverSqlite, Sqlite: config.Sqlite{Path: filepath.Join(t.TempDir(), "blogo.db")}, Migrate: config.MigrateCheck}}
+
+	if _, err := database.Connect(cfg); err == nil {
+		t.Fatal("Connect() in check mode accepted a database with pending migrations")
+	}
+
+	db, err := database.Open(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if db.Migrator().HasTable("schema_migrations") {
+		t.Error("check mode created schema_migrations")
+	}
+
+	statuses, err := database.MigrationsStatus(context.Background(), db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, status := range statuses {
+		if status.Applied() {
+			t.Errorf("migration %d is applied on an empty database", status.Version)
+		}
+	}
+}
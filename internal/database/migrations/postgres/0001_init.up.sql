@@ -0,0 +1,146 @@
+-- baseline schema which AutoMigrate used to create. every statement is
+-- guarded so databases created before migrations adopt it as is.
+
+CREATE TABLE IF NOT EXISTS users (
+	id bigserial PRIMARY KEY,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	first_name text,
+	last_name text,
+	username text,
+	password text,
+	email text,
+	role text,
+	active boolean,
+	skill text,
+	last_visited timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
+
+CREATE TABLE IF NOT EXISTS user_follows (
+	followed_id bigint,
+	follower_id bigint,
+	PRIMARY KEY (followed_id, follower_id),
+	CONSTRAINT fk_user_follows_user FOREIGN KEY (followed_id) REFERENCES users (id),
+	CONSTRAINT fk_user_follows_followers FOREIGN KEY (follower_id) REFERENCES users (id)
+);
+
+CREATE TABLE IF NOT EXISTS posts (
+	id bigserial PRIMARY KEY,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	title text,
+	slug text,
+	content text,
+	format text DEFAULT 'markdown',
+	content_html text,
+	status text DEFAULT 'published',
+	published_at timestamptz,
+	user_refer bigint,
+	CONSTRAINT fk_users_posts FOREIGN KEY (user_refer) REFERENCES users (id)
+);
+CREATE INDEX IF NOT EXISTS idx_posts_status ON posts (status);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_posts_slug ON posts (slug) WHERE slug <> '';
+CREATE INDEX IF NOT EXISTS idx_posts_deleted_at ON posts (deleted_at);
+
+CREATE TABLE IF NOT EXISTS likes (
+	user_id bigint,
+	post_id bigint,
+	PRIMARY KEY (user_id, post_id),
+	CONSTRAINT fk_likes_user FOREIGN KEY (user_id) REFERENCES users (id),
+	CONSTRAINT fk_likes_post FOREIGN KEY (post_id) REFERENCES posts (id)
+);
+
+CREATE TABLE IF NOT EXISTS tags (
+	id bigserial PRIMARY KEY,
+	name text,
+	slug text,
+	created_at timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tags_slug ON tags (slug);
+
+CREATE TABLE IF NOT EXISTS post_tags (
+	post_id bigint,
+	tag_id bigint,
+	PRIMARY KEY (post_id, tag_id),
+	CONSTRAINT fk_post_tags_post FOREIGN KEY (post_id) REFERENCES posts (id),
+	CONSTRAINT fk_post_tags_tag FOREIGN KEY (tag_id) REFERENCES tags (id)
+);
+
+CREATE TABLE IF NOT EXISTS tag_aliases (
+	slug text PRIMARY KEY,
+	tag_refer bigint
+);
+CREATE INDEX IF NOT EXISTS idx_tag_aliases_tag_refer ON tag_aliases (tag_refer);
+
+CREATE TABLE IF NOT EXISTS categories (
+	id bigserial PRIMARY KEY,
+	name text,
+	slug text,
+	description text,
+	created_at timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_categories_slug ON categories (slug);
+
+CREATE TABLE IF NOT EXISTS post_categories (
+	post_id bigint,
+	category_id bigint,
+	PRIMARY KEY (post_id, category_id),
+	CONSTRAINT fk_post_categories_post FOREIGN KEY (post_id) REFERENCES posts (id),
+	CONSTRAINT fk_post_categories_category FOREIGN KEY (category_id) REFERENCES categories (id)
+);
+
+CREATE TABLE IF NOT EXISTS sessions (
+	id bigserial PRIMARY KEY,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	user_refer bigint,
+	refresh_token_hash text,
+	previous_token_hash text,
+	user_agent text,
+	ip text,
+	expires_at timestamptz,
+	revoked_at timestamptz,
+	CONSTRAINT fk_sessions_user FOREIGN KEY (user_refer) REFERENCES users (id)
+);
+CREATE INDEX IF NOT EXISTS idx_sessions_previous_token_hash ON sessions (previous_token_hash);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sessions_refresh_token_hash ON sessions (refresh_token_hash);
+CREATE INDEX IF NOT EXISTS idx_sessions_user_refer ON sessions (user_refer);
+CREATE INDEX IF NOT EXISTS idx_sessions_deleted_at ON sessions (deleted_at);
+
+CREATE TABLE IF NOT EXISTS comments (
+	id bigserial PRIMARY KEY,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	post_refer bigint,
+	user_refer bigint,
+	parent_id bigint,
+	content text
+);
+CREATE INDEX IF NOT EXISTS idx_comments_post_refer ON comments (post_refer);
+CREATE INDEX IF NOT EXISTS idx_comments_user_refer ON comments (user_refer);
+CREATE INDEX IF NOT EXISTS idx_comments_parent_id ON comments (parent_id);
+CREATE INDEX IF NOT EXISTS idx_comments_deleted_at ON comments (deleted_at);
+
+CREATE TABLE IF NOT EXISTS post_slugs (
+	slug text PRIMARY KEY,
+	post_refer bigint,
+	created_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_post_slugs_post_refer ON post_slugs (post_refer);
+
+CREATE TABLE IF NOT EXISTS post_revisions (
+	id bigserial PRIMARY KEY,
+	post_refer bigint,
+	number bigint,
+	title text,
+	content text,
+	format text,
+	editor_refer bigint,
+	created_at timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_post_revisions_number ON post_revisions (post_refer, number);
@@ -0,0 +1,16 @@
+-- generated tsvector columns and GIN indexes used by full-text search.
+
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS search_vector tsvector
+	GENERATED ALWAYS AS (
+		setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+		setweight(to_tsvector('english', coalesce(content, '')), 'B')
+	) STORED;
+CREATE INDEX IF NOT EXISTS idx_posts_search_vector ON posts USING GIN (search_vector);
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS search_vector tsvector
+	GENERATED ALWAYS AS (
+		setweight(to_tsvector('simple', coalesce(username, '')), 'A') ||
+		setweight(to_tsvector('simple', coalesce(first_name, '') || ' ' || coalesce(last_name, '')), 'A') ||
+		setweight(to_tsvector('simple', coalesce(skill, '')), 'B')
+	) STORED;
+CREATE INDEX IF NOT EXISTS idx_users_search_vector ON users USING GIN (search_vector);
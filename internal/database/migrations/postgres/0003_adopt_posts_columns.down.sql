@@ -0,0 +1,2 @@
+-- nothing to roll back, the columns belong to 0001_init and published_at
+-- can't tell backfilled values from real ones.
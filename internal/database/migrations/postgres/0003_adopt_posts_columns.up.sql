@@ -0,0 +1,12 @@
+-- databases which applied 0001_init before it adopted AutoMigrate schemas may
+-- miss the columns posts gained later. published posts of those databases
+-- have no published_at, their creation time is the closest value.
+
+ALTER TABLE posts
+	ADD COLUMN IF NOT EXISTS slug text,
+	ADD COLUMN IF NOT EXISTS format text DEFAULT 'markdown',
+	ADD COLUMN IF NOT EXISTS content_html text,
+	ADD COLUMN IF NOT EXISTS status text DEFAULT 'published',
+	ADD COLUMN IF NOT EXISTS published_at timestamptz;
+
+UPDATE posts SET published_at = created_at WHERE status = 'published' AND published_at IS NULL;
@@ -0,0 +1,2 @@
+-- nothing to roll back, backfilled slugs can't be told from the others and
+-- links to them may be out already.
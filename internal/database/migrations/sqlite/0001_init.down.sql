@@ -0,0 +1,13 @@
+DROP TABLE IF EXISTS post_revisions;
+DROP TABLE IF EXISTS post_slugs;
+DROP TABLE IF EXISTS comments;
+DROP TABLE IF EXISTS sessions;
+DROP TABLE IF EXISTS post_categories;
+DROP TABLE IF EXISTS categories;
+DROP TABLE IF EXISTS tag_aliases;
+DROP TABLE IF EXISTS post_tags;
+DROP TABLE IF EXISTS tags;
+DROP TABLE IF EXISTS likes;
+DROP TABLE IF EXISTS posts;
+DROP TABLE IF EXISTS user_follows;
+DROP TABLE IF EXISTS users;
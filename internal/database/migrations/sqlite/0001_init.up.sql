@@ -0,0 +1,146 @@
+-- the baseline schema of 0001_init in postgres, in the types sqlite and its
+-- driver understand.
+
+CREATE TABLE IF NOT EXISTS users (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	created_at datetime,
+	updated_at datetime,
+	deleted_at datetime,
+	first_name text,
+	last_name text,
+	username text,
+	password text,
+	email text,
+	role text,
+	active numeric,
+	skill text,
+	last_visited datetime
+);
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
+
+CREATE TABLE IF NOT EXISTS user_follows (
+	followed_id integer,
+	follower_id integer,
+	PRIMARY KEY (followed_id, follower_id),
+	CONSTRAINT fk_user_follows_user FOREIGN KEY (followed_id) REFERENCES users (id),
+	CONSTRAINT fk_user_follows_followers FOREIGN KEY (follower_id) REFERENCES users (id)
+);
+
+CREATE TABLE IF NOT EXISTS posts (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	created_at datetime,
+	updated_at datetime,
+	deleted_at datetime,
+	title text,
+	slug text,
+	content text,
+	format text DEFAULT 'markdown',
+	content_html text,
+	status text DEFAULT 'published',
+	published_at datetime,
+	user_refer integer,
+	CONSTRAINT fk_users_posts FOREIGN KEY (user_refer) REFERENCES users (id)
+);
+CREATE INDEX IF NOT EXISTS idx_posts_status ON posts (status);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_posts_slug ON posts (slug) WHERE slug <> '';
+CREATE INDEX IF NOT EXISTS idx_posts_deleted_at ON posts (deleted_at);
+
+CREATE TABLE IF NOT EXISTS likes (
+	user_id integer,
+	post_id integer,
+	PRIMARY KEY (user_id, post_id),
+	CONSTRAINT fk_likes_user FOREIGN KEY (user_id) REFERENCES users (id),
+	CONSTRAINT fk_likes_post FOREIGN KEY (post_id) REFERENCES posts (id)
+);
+
+CREATE TABLE IF NOT EXISTS tags (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	name text,
+	slug text,
+	created_at datetime
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tags_slug ON tags (slug);
+
+CREATE TABLE IF NOT EXISTS post_tags (
+	post_id integer,
+	tag_id integer,
+	PRIMARY KEY (post_id, tag_id),
+	CONSTRAINT fk_post_tags_post FOREIGN KEY (post_id) REFERENCES posts (id),
+	CONSTRAINT fk_post_tags_tag FOREIGN KEY (tag_id) REFERENCES tags (id)
+);
+
+CREATE TABLE IF NOT EXISTS tag_aliases (
+	slug text PRIMARY KEY,
+	tag_refer integer
+);
+CREATE INDEX IF NOT EXISTS idx_tag_aliases_tag_refer ON tag_aliases (tag_refer);
+
+CREATE TABLE IF NOT EXISTS categories (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	name text,
+	slug text,
+	description text,
+	created_at datetime
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_categories_slug ON categories (slug);
+
+CREATE TABLE IF NOT EXISTS post_categories (
+	post_id integer,
+	category_id integer,
+	PRIMARY KEY (post_id, category_id),
+	CONSTRAINT fk_post_categories_post FOREIGN KEY (post_id) REFERENCES posts (id),
+	CONSTRAINT fk_post_categories_category FOREIGN KEY (category_id) REFERENCES categories (id)
+);
+
+CREATE TABLE IF NOT EXISTS sessions (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	created_at datetime,
+	updated_at datetime,
+	deleted_at datetime,
+	user_refer integer,
+	refresh_token_hash text,
+	previous_token_hash text,
+	user_agent text,
+	ip text,
+	expires_at datetime,
+	revoked_at datetime,
+	CONSTRAINT fk_sessions_user FOREIGN KEY (user_refer) REFERENCES users (id)
+);
+CREATE INDEX IF NOT EXISTS idx_sessions_previous_token_hash ON sessions (previous_token_hash);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sessions_refresh_token_hash ON sessions (refresh_token_hash);
+CREATE INDEX IF NOT EXISTS idx_sessions_user_refer ON sessions (user_refer);
+CREATE INDEX IF NOT EXISTS idx_sessions_deleted_at ON sessions (deleted_at);
+
+CREATE TABLE IF NOT EXISTS comments (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	created_at datetime,
+	updated_at datetime,
+	deleted_at datetime,
+	post_refer integer,
+	user_refer integer,
+	parent_id integer,
+	content text
+);
+CREATE INDEX IF NOT EXISTS idx_comments_post_refer ON comments (post_refer);
+CREATE INDEX IF NOT EXISTS idx_comments_user_refer ON comments (user_refer);
+CREATE INDEX IF NOT EXISTS idx_comments_parent_id ON comments (parent_id);
+CREATE INDEX IF NOT EXISTS idx_comments_deleted_at ON comments (deleted_at);
+
+CREATE TABLE IF NOT EXISTS post_slugs (
+	slug text PRIMARY KEY,
+	post_refer integer,
+	created_at datetime
+);
+CREATE INDEX IF NOT EXISTS idx_post_slugs_post_refer ON post_slugs (post_refer);
+
+CREATE TABLE IF NOT EXISTS post_revisions (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	post_refer integer,
+	number integer,
+	title text,
+	content text,
+	format text,
+	editor_refer integer,
+	created_at datetime
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_post_revisions_number ON post_revisions (post_refer, number);
@@ -0,0 +1 @@
+-- nothing to roll back, see 0002_search.up.sql.
@@ -0,0 +1,2 @@
+-- sqlite has no tsvector, search falls back to LIKE on the plain columns.
+-- this migration only keeps the versions in step with postgres.
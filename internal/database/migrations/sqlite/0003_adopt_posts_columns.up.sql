@@ -0,0 +1,4 @@
+-- sqlite databases always had these columns when 0001_init was applied, only
+-- the published_at of published posts is backfilled, like in postgres.
+
+UPDATE posts SET published_at = created_at WHERE status = 'published' AND published_at IS NULL;
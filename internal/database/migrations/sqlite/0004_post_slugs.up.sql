@@ -0,0 +1,2 @@
+-- posts created before posts had slugs get one from their title. slugs are
+-- made in go, see backfillPostSlugs in migrate.go.
@@ -0,0 +1,4 @@
+-- usernames and emails are only unique among users who aren't in the trash,
+-- so a trashed user keeps them until it is restored or purged.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_username ON users (username) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (lower(email)) WHERE deleted_at IS NULL AND email <> '';
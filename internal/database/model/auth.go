@@ -1,15 +1,19 @@
 package model
 
 type RegisterInput struct {
-	FirstName string `json:"first_name,omitempty"`
-	LastName  string `json:"last_name,omitempty"`
-	Username  string `json:"username"`
-	Password  string `json:"password"`
-	Email     string `json:"email"`
-	Skill     string `json:"skill"`
+	FirstName string `json:"first_name,omitempty" binding:"max=50"`
+	LastName  string `json:"last_name,omitempty" binding:"max=50"`
+	Username  string `json:"username" binding:"required,username"`
+	Password  string `json:"password" binding:"required,password"`
+	Email     string `json:"email" binding:"required,email"`
+	Skill     string `json:"skill" binding:"max=200"`
 }
 
 type LoginInput struct {
-	Username string `json:"username"`
-	Password string `json:"password"`
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+type RefreshInput struct {
+	RefreshToken string `json:"refresh_token"`
 }
@@ -0,0 +1,81 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Category struct {
+	ID          uint      `json:"id" gorm:"primarykey"`
+	Name        string    `json:"name"`
+	Slug        string    `json:"slug" gorm:"uniqueIndex"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type CategoryCount struct {
+	Name  string `json:"name"`
+	Slug  string `json:"slug"`
+	Count int64  `json:"count"`
+}
+
+type CategoriesInput struct {
+	Categories []string `json:"categories"`
+}
+
+func (c *Category) CreateCategory(db *gorm.DB, categoryBody *Category) error {
+	if err := db.Create(&categoryBody).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (c *Category) GetCategoryBySlug(db *gorm.DB, slug string) (*Category, error) {
+	var category *Category
+	if err := db.Table("categories").Where("slug=?", slug).Find(&category).Error; err != nil {
+		return nil, err
+	}
+
+	if category.ID == 0 {
+		return nil, fmt.Errorf("category %w", ErrNotFound)
+	}
+
+	return category, nil
+}
+
+func (c *Category) AttachCategories(db *gorm.DB, postID uint, categories []Category) error {
+	for _, category := range categories {
+		if err := db.Table("post_categories").Clauses(clause.OnConflict{DoNothing: true}).Create(map[string]interface{}{
+			"post_id":     postID,
+			"category_id": category.ID,
+		}).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *Category) DetachCategory(db *gorm.DB, postID uint, categoryID uint) error {
+	return db.Table("post_categories").Where("post_id = ? AND category_id = ?", postID, categoryID).Delete(nil).Error
+}
+
+// GetCategoryCounts lists every category with its number of published posts.
+func (c *Category) GetCategoryCounts(db *gorm.DB) (*[]CategoryCount, error) {
+	var counts []CategoryCount
+	if err := db.Table("categories").
+		Select("categories.name, categories.slug, count(posts.id) AS count").
+		Joins("LEFT JOIN post_categories ON post_categories.category_id = categories.id").
+		Joins("LEFT JOIN posts ON posts.id = post_categories.post_id AND posts.deleted_at IS NULL AND posts.status = ?", PostStatusPublished).
+		Group("categories.id, categories.name, categories.slug").
+		Order("categories.name").
+		Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+
+	return &counts, nil
+}
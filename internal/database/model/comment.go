@@ -0,0 +1,100 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Comment struct {
+	gorm.Model
+	PostRefer uint   `json:"post_id" gorm:"index"`
+	UserRefer uint   `json:"user_id" gorm:"index"`
+	ParentID  *uint  `json:"parent_id" gorm:"index"`
+	Content   string `json:"content"`
+}
+
+type CommentInput struct {
+	Content  string `json:"content" binding:"required,max=2000"`
+	ParentID *uint  `json:"parent_id"`
+}
+
+type CommentResponse struct {
+	ID        uint               `json:"id"`
+	PostRefer uint               `json:"post_id"`
+	UserRefer uint               `json:"user_id"`
+	ParentID  *uint              `json:"parent_id"`
+	Content   string             `json:"content"`
+	Deleted   bool               `json:"deleted"`
+	CreatedAt time.Time          `json:"created_at"`
+	UpdatedAt time.Time          `json:"updated_at"`
+	Replies   []*CommentResponse `json:"replies"`
+}
+
+func (c *Comment) CreateComment(db *gorm.DB, commentBody *Comment) error {
+	if err := db.Create(&commentBody).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (c *Comment) GetCommentByID(db *gorm.DB, commentID int) (*Comment, error) {
+	var comment *Comment
+	if err := db.Table("comments").Where("id=?", commentID).Find(&comment).Error; err != nil {
+		return nil, err
+	}
+
+	if comment.ID == 0 {
+		return nil, fmt.Errorf("comment %w", ErrNotFound)
+	}
+
+	return comment, nil
+}
+
+// GetCommentsByPostID returns every comment of the post in creation order,
+// including soft deleted ones so their replies keep a parent.
+func (c *Comment) GetCommentsByPostID(db *gorm.DB, postID uint) (*[]Comment, error) {
+	var comments []Comment
+	if err := db.Unscoped().Table("comments").Where("post_refer=?", postID).Order("created_at, id").Find(&comments).Error; err != nil {
+		return nil, err
+	}
+
+	return &comments, nil
+}
+
+func (c *Comment) UpdateCommentByID(db *gorm.DB, commentBody *Comment) error {
+	if err := db.Save(&commentBody).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (c *Comment) DeleteCommentByID(db *gorm.DB, commentID uint) error {
+	return db.Delete(&Comment{}, commentID).Error
+}
+
+// CountCommentsByPostIDs counts the comments which aren't deleted of every
+// given post.
+func (c *Comment) CountCommentsByPostIDs(db *gorm.DB, postIDs []uint) (map[uint]int64, error) {
+	type row struct {
+		PostRefer uint
+		Count     int64
+	}
+
+	var rows []row
+	if len(postIDs) > 0 {
+		if err := db.Model(&Comment{}).Select("post_refer, count(*) AS count").Where("post_refer IN ?", postIDs).Group("post_refer").Scan(&rows).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	counts := make(map[uint]int64, len(rows))
+	for _, r := range rows {
+		counts[r.PostRefer] = r.Count
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,21 @@
+package model
+
+import "errors"
+
+// ErrNotFound is wrapped by every lookup which finds nothing, the error reads
+// like "post not found".
+var ErrNotFound = errors.New("not found")
+
+// ErrTokenReused is returned when a refresh token is rotated twice.
+var ErrTokenReused = errors.New("refresh token already used")
+
+// QueryError is returned for a list query with an invalid field, such as a
+// cursor which can't be decoded.
+type QueryError struct {
+	Field   string
+	Message string
+}
+
+func (e *QueryError) Error() string {
+	return e.Message
+}
@@ -0,0 +1,220 @@
+package model
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	SortNewest = "newest"
+	SortOldest = "oldest"
+
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// PageQuery is the cursor pagination part of every list query.
+type PageQuery struct {
+	Limit  int    `form:"limit" json:"limit"`
+	Cursor string `form:"cursor" json:"cursor"`
+	Sort   string `form:"sort" json:"sort"`
+}
+
+// Page is returned next to every list so clients can fetch the next one.
+type Page struct {
+	NextCursor string `json:"next_cursor"`
+	HasMore    bool   `json:"has_more"`
+}
+
+// cursor points at the last row of a page. it is handed to clients as an
+// opaque base64 string so the encoding can change without breaking them.
+// CreatedAt holds the time column the rows are ordered by, which isn't
+// created_at for lists paginated with PaginateBy.
+type cursor struct {
+	CreatedAt time.Time `json:"c,omitempty"`
+	ID        uint      `json:"i,omitempty"`
+	Sort      string    `json:"s,omitempty"`
+	Offset    int       `json:"o,omitempty"`
+}
+
+// Paginate validates the query and returns a scope which orders the rows of
+// table by (created_at, id) and skips everything up to the cursor. it fetches
+// one row more than the limit so NextPage can tell if there are more rows.
+func (q *PageQuery) Paginate(table string) (func(db *gorm.DB) *gorm.DB, error) {
+	return q.PaginateBy(table, "created_at")
+}
+
+// PaginateBy is Paginate on (column, id), for lists ordered by another time
+// column than created_at.
+func (q *PageQuery) PaginateBy(table, column string) (func(db *gorm.DB) *gorm.DB, error) {
+	after, err := q.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return func(db *gorm.DB) *gorm.DB {
+		order, op := "DESC", "<"
+		if q.Sort == SortOldest {
+			order, op = "ASC", ">"
+		}
+
+		if after != nil {
+			db = db.Where(
+				fmt.Sprintf("(%[1]s.%[3]s %[2]s ? OR (%[1]s.%[3]s = ? AND %[1]s.id %[2]s ?))", table, op, column),
+				after.CreatedAt, after.CreatedAt, after.ID,
+			)
+		}
+
+		return db.
+			Order(fmt.Sprintf("%s.%s %s", table, column, order)).
+			Order(fmt.Sprintf("%s.id %s", table, order)).
+			Limit(q.Limit + 1)
+	}, nil
+}
+
+// PageRows is Paginate and NextPage for rows which are already in memory.
+// it orders the rows by (created_at, id) and returns the page after the
+// cursor.
+func PageRows[T any](rows []T, q PageQuery, key func(row T) (time.Time, uint)) ([]T, Page, error) {
+	after, err := q.validate()
+	if err != nil {
+		return nil, Page{}, err
+	}
+
+	// before reports whether a comes first in the order of the query
+	before := func(aCreatedAt time.Time, aID uint, bCreatedAt time.Time, bID uint) bool {
+		if !aCreatedAt.Equal(bCreatedAt) {
+			return aCreatedAt.After(bCreatedAt) == (q.Sort == SortNewest)
+		}
+		return aID != bID && (aID > bID) == (q.Sort == SortNewest)
+	}
+
+	sorted := make([]T, 0, len(rows))
+	for _, row := range rows {
+		createdAt, id := key(row)
+		if after != nil && !before(after.CreatedAt, after.ID, createdAt, id) {
+			continue
+		}
+		sorted = append(sorted, row)
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		iCreatedAt, iID := key(sorted[i])
+		jCreatedAt, jID := key(sorted[j])
+		return before(iCreatedAt, iID, jCreatedAt, jID)
+	})
+
+	if len(sorted) > q.Limit+1 {
+		sorted = sorted[:q.Limit+1]
+	}
+
+	page, next := NextPage(sorted, q, key)
+	return page, next, nil
+}
+
+// validate applies the defaults of the query and decodes its cursor.
+func (q *PageQuery) validate() (*cursor, error) {
+	if q.Limit <= 0 {
+		q.Limit = defaultPageLimit
+	}
+	if q.Limit > maxPageLimit {
+		q.Limit = maxPageLimit
+	}
+
+	if q.Sort == "" {
+		q.Sort = SortNewest
+	}
+	if q.Sort != SortNewest && q.Sort != SortOldest {
+		return nil, &QueryError{Field: "sort", Message: fmt.Sprintf("sort must be %s or %s", SortNewest, SortOldest)}
+	}
+
+	var after *cursor
+	if q.Cursor != "" {
+		c, err := decodeCursor(q.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if c.Sort != q.Sort {
+			return nil, &QueryError{Field: "cursor", Message: "cursor doesn't match the sort"}
+		}
+		after = c
+	}
+
+	return after, nil
+}
+
+// NextPage drops the extra row fetched by Paginate and builds the page info
+// from the last row that is returned.
+func NextPage[T any](rows []T, q PageQuery, key func(row T) (time.Time, uint)) ([]T, Page) {
+	if len(rows) <= q.Limit {
+		return rows, Page{}
+	}
+
+	rows = rows[:q.Limit]
+	createdAt, id := key(rows[len(rows)-1])
+
+	return rows, Page{
+		NextCursor: encodeCursor(cursor{CreatedAt: createdAt, ID: id, Sort: q.Sort}),
+		HasMore:    true,
+	}
+}
+
+// Offset validates the query for result sets that can't be keyset paginated,
+// such as ranked search results, and returns the offset of the cursor.
+func (q *PageQuery) Offset() (int, error) {
+	if q.Limit <= 0 {
+		q.Limit = defaultPageLimit
+	}
+	if q.Limit > maxPageLimit {
+		q.Limit = maxPageLimit
+	}
+
+	if q.Cursor == "" {
+		return 0, nil
+	}
+
+	c, err := decodeCursor(q.Cursor)
+	if err != nil {
+		return 0, err
+	}
+	if c.Offset < 0 {
+		return 0, &QueryError{Field: "cursor", Message: "cursor is invalid"}
+	}
+
+	return c.Offset, nil
+}
+
+// NextOffsetPage is the NextPage of offset paginated queries.
+func NextOffsetPage[T any](rows []T, q PageQuery, offset int) ([]T, Page) {
+	if len(rows) <= q.Limit {
+		return rows, Page{}
+	}
+
+	return rows[:q.Limit], Page{
+		NextCursor: encodeCursor(cursor{Offset: offset + q.Limit}),
+		HasMore:    true,
+	}
+}
+
+func encodeCursor(c cursor) string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(s string) (*cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, &QueryError{Field: "cursor", Message: "cursor is invalid"}
+	}
+
+	var c cursor
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, &QueryError{Field: "cursor", Message: "cursor is invalid"}
+	}
+
+	return &c, nil
+}
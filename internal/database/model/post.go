@@ -2,55 +2,196 @@ package model
 
 import (
 	"fmt"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	PostStatusDraft     = "draft"
+	PostStatusScheduled = "scheduled"
+	PostStatusPublished = "published"
+	PostStatusArchived  = "archived"
 )
 
 type Post struct {
 	gorm.Model
-	Title     string `json:"title"`
-	Content   string `json:"content"`
-	UserRefer uint   `json:"user_id"`
-	LikedBy   []User `gorm:"many2many:likes;"`
+	Title       string     `json:"title" binding:"required,max=200"`
+	Slug        string     `json:"slug" gorm:"uniqueIndex:idx_posts_slug,where:slug <> ''" binding:"max=200"`
+	Content     string     `json:"content"`
+	Format      string     `json:"format" gorm:"default:markdown" binding:"omitempty,oneof=markdown html"`
+	ContentHTML string     `json:"content_html"`
+	Status      string     `json:"status" gorm:"default:published;index" binding:"omitempty,oneof=published draft scheduled archived"`
+	PublishedAt *time.Time `json:"published_at"`
+	UserRefer   uint       `json:"user_id"`
+	LikedBy     []User     `gorm:"many2many:likes;"`
+	Tags        []Tag      `json:"tags,omitempty" gorm:"many2many:post_tags;"`
+	Categories  []Category `json:"categories,omitempty" gorm:"many2many:post_categories;"`
 }
 
 type PostResponse struct {
-	ID         uint   `json:"id"`
-	Title      string `json:"title"`
-	Content    string `json:"content"`
-	UserRefer  uint   `json:"user_id"`
-	Liked      bool   `json:"liked"`
-	LikedCount int    `json:"liked_count"`
+	ID           uint       `json:"id"`
+	Title        string     `json:"title"`
+	Slug         string     `json:"slug"`
+	Content      string     `json:"content"`
+	Format       string     `json:"format"`
+	ContentHTML  string     `json:"content_html"`
+	Status       string     `json:"status"`
+	PublishedAt  *time.Time `json:"published_at"`
+	UserRefer    uint       `json:"user_id"`
+	Liked        bool       `json:"liked"`
+	LikedCount   int        `json:"liked_count"`
+	CommentCount int64      `json:"comment_count"`
+	Tags         []Tag      `json:"tags"`
+	Categories   []Category `json:"categories"`
+}
+
+// IsVisibleTo reports whether the user can see the post. posts which are
+// not published yet are only visible to their author.
+func (p *Post) IsVisibleTo(userID uint) bool {
+	return p.Status == PostStatusPublished || p.UserRefer == userID
+}
+
+// VisiblePosts scopes a posts query to the posts the user can see.
+func VisiblePosts(userID uint) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("posts.status = ? OR posts.user_refer = ?", PostStatusPublished, userID)
+	}
+}
+
+// PostQuery filters and paginates the post list.
+type PostQuery struct {
+	PageQuery
+	AuthorID  uint       `form:"author_id"`
+	From      *time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To        *time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	LikedByMe bool       `form:"liked_by_me"`
+	Tag       string     `form:"tag"`
+	Category  string     `form:"category"`
+}
+
+// UpdatePostInput holds the fields of a post update. fields left out of the
+// request are nil and keep their value.
+type UpdatePostInput struct {
+	Title       *string    `json:"title" binding:"omitnil,min=1,max=200"`
+	Content     *string    `json:"content"`
+	Format      *string    `json:"format" binding:"omitnil,oneof=markdown html"`
+	Status      *string    `json:"status" binding:"omitnil,oneof=published draft scheduled archived"`
+	PublishedAt *time.Time `json:"published_at"`
+	Slug        *string    `json:"slug" binding:"omitnil,max=200"`
+	// UserID is only bound to reject it, posts change author by transfer
+	UserID *uint `json:"user_id"`
+}
+
+type TransferPostInput struct {
+	UserID uint `json:"user_id"`
 }
 
 func (p *Post) CreatePost(db *gorm.DB, postBody *Post) error {
 	post := Post{
-		Title:     postBody.Title,
-		Content:   postBody.Content,
-		UserRefer: postBody.UserRefer,
+		Title:       postBody.Title,
+		Slug:        postBody.Slug,
+		Content:     postBody.Content,
+		Format:      postBody.Format,
+		ContentHTML: postBody.ContentHTML,
+		Status:      postBody.Status,
+		PublishedAt: postBody.PublishedAt,
+		UserRefer:   postBody.UserRefer,
 	}
 
-	if err := db.Create(&post).Error; err != nil {
-		return err
-	}
-	return nil
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&post).Error; err != nil {
+			return err
+		}
+
+		if err := createRevision(tx, &post, post.UserRefer); err != nil {
+			return err
+		}
+
+		// hand the id and the defaults of the new post back to the caller
+		*postBody = post
+		return nil
+	})
 }
 
-func (p *Post) GetAllPosts(db *gorm.DB) (*[]Post, error) {
-	var posts *[]Post
-	if err := db.Table("posts").Preload("LikedBy").Find(&posts).Error; err != nil {
-		return nil, err
+func (p *Post) GetAllPosts(db *gorm.DB, viewerID uint, query PostQuery) (*[]Post, *Page, error) {
+	var posts []Post
+
+	paginate, err := query.Paginate("posts")
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return posts, nil
+	tx := db.Table("posts").Scopes(VisiblePosts(viewerID), paginate)
+	if query.AuthorID != 0 {
+		tx = tx.Where("posts.user_refer = ?", query.AuthorID)
+	}
+	if query.From != nil {
+		tx = tx.Where("posts.created_at >= ?", query.From)
+	}
+	if query.To != nil {
+		tx = tx.Where("posts.created_at < ?", query.To)
+	}
+	if query.LikedByMe {
+		tx = tx.Where("EXISTS (SELECT 1 FROM likes WHERE likes.post_id = posts.id AND likes.user_id = ?)", viewerID)
+	}
+	if query.Tag != "" {
+		tx = tx.Where("EXISTS (SELECT 1 FROM post_tags JOIN tags ON tags.id = post_tags.tag_id WHERE post_tags.post_id = posts.id AND tags.slug = ?)", query.Tag)
+	}
+	if query.Category != "" {
+		tx = tx.Where("EXISTS (SELECT 1 FROM post_categories JOIN categories ON categories.id = post_categories.category_id WHERE post_categories.post_id = posts.id AND categories.slug = ?)", query.Category)
+	}
+
+	if err := tx.Preload("LikedBy").Preload("Tags").Preload("Categories").Find(&posts).Error; err != nil {
+		return nil, nil, err
+	}
+
+	posts, page := NextPage(posts, query.PageQuery, func(post Post) (time.Time, uint) {
+		return post.CreatedAt, post.ID
+	})
+
+	return &posts, &page, nil
 }
 
-func (p *Post) UpdatePostByID(db *gorm.DB, postBody *Post) error {
-	if err := db.Save(&postBody).Error; err != nil {
-		return err
+// UpdatePostByID saves the post and records the result as a new revision
+// edited by editorID. a changed slug keeps the old one in the slug history.
+func (p *Post) UpdatePostByID(db *gorm.DB, postBody *Post, editorID uint) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := lockPost(tx, postBody.ID); err != nil {
+			return err
+		}
+
+		if err := ensureFirstRevision(tx, postBody.ID); err != nil {
+			return err
+		}
+
+		if err := keepOldSlug(tx, postBody); err != nil {
+			return err
+		}
+
+		// likes, tags and categories are changed by their own methods
+		if err := tx.Omit(clause.Associations).Save(&postBody).Error; err != nil {
+			return err
+		}
+
+		return createRevision(tx, postBody, editorID)
+	})
+}
+
+// PublishScheduledPosts publishes every scheduled post whose publish time
+// has come and returns how many posts were published.
+func (p *Post) PublishScheduledPosts(db *gorm.DB, now time.Time) (int64, error) {
+	result := db.Model(&Post{}).Where("status = ? AND published_at <= ?", PostStatusScheduled, now).Update("status", PostStatusPublished)
+	if result.Error != nil {
+		return 0, result.Error
 	}
 
-	return nil
+	return result.RowsAffected, nil
+}
+
+func (p *Post) TransferPost(db *gorm.DB, postID, userID uint) error {
+	return db.Model(&Post{}).Where("id=?", postID).Update("user_refer", userID).Error
 }
 
 func (p *Post) DeletePostByID(db *gorm.DB, postID int) error {
@@ -60,7 +201,7 @@ func (p *Post) DeletePostByID(db *gorm.DB, postID int) error {
 	}
 
 	if post.ID == 0 {
-		return fmt.Errorf("post not found")
+		return fmt.Errorf("post %w", ErrNotFound)
 	}
 
 	if err := db.Table("posts").Delete(&post).Error; err != nil {
@@ -72,12 +213,12 @@ func (p *Post) DeletePostByID(db *gorm.DB, postID int) error {
 
 func (p *Post) GetPostByID(db *gorm.DB, postID int) (*Post, error) {
 	var post *Post
-	if err := db.Table("posts").Where("id=?", postID).Preload("LikedBy").Find(&post).Error; err != nil {
+	if err := db.Table("posts").Where("id=?", postID).Preload("LikedBy").Preload("Tags").Preload("Categories").Find(&post).Error; err != nil {
 		return nil, err
 	}
 
 	if post.ID == 0 {
-		return nil, fmt.Errorf("post not found")
+		return nil, fmt.Errorf("post %w", ErrNotFound)
 	}
 
 	return post, nil
@@ -90,6 +231,15 @@ func (p *Post) LikePostByID(db *gorm.DB, userID, postID uint) error {
 	}).Error
 }
 
+func (p *Post) IsPostLiked(db *gorm.DB, userID, postID uint) (bool, error) {
+	var count int64
+	if err := db.Table("likes").Where("user_id = ? AND post_id = ?", userID, postID).Count(&count).Error; err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
 func (p *Post) UnlikePostByID(db *gorm.DB, userID, postID uint) error {
 	return db.Table("likes").Where("user_id = ? AND post_id = ?", userID, postID).Delete(nil).Error
 }
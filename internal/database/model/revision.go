@@ -0,0 +1,119 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PostRevision is an immutable snapshot of a post, written every time the
+// post is created or updated.
+type PostRevision struct {
+	ID          uint      `json:"id" gorm:"primarykey"`
+	PostRefer   uint      `json:"post_id" gorm:"uniqueIndex:idx_post_revisions_number"`
+	Number      int       `json:"number" gorm:"uniqueIndex:idx_post_revisions_number"`
+	Title       string    `json:"title"`
+	Content     string    `json:"content"`
+	Format      string    `json:"format"`
+	EditorRefer uint      `json:"editor_id"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// RevisionDiffQuery selects the two revisions to diff.
+type RevisionDiffQuery struct {
+	From int `form:"from"`
+	To   int `form:"to"`
+}
+
+// RevisionDiff is a unified diff between two revisions of a post.
+type RevisionDiff struct {
+	From int    `json:"from"`
+	To   int    `json:"to"`
+	Diff string `json:"diff"`
+}
+
+// lockPost locks the row of the post until the transaction ends, so
+// concurrent updates of the post take their revision numbers one after the
+// other. sqlite has no row locks, it lets a single transaction write anyway.
+func lockPost(tx *gorm.DB, postID uint) error {
+	return tx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).Unscoped().Select("id").Where("id=?", postID).Find(&Post{}).Error
+}
+
+// createRevision snapshots the post as its next revision. updates lock the
+// post first, see lockPost.
+func createRevision(db *gorm.DB, post *Post, editorID uint) error {
+	var last int
+	if err := db.Model(&PostRevision{}).Where("post_refer = ?", post.ID).Select("COALESCE(MAX(number), 0)").Scan(&last).Error; err != nil {
+		return err
+	}
+
+	revision := PostRevision{
+		PostRefer:   post.ID,
+		Number:      last + 1,
+		Title:       post.Title,
+		Content:     post.Content,
+		Format:      post.Format,
+		EditorRefer: editorID,
+	}
+
+	return db.Create(&revision).Error
+}
+
+// ensureFirstRevision snapshots the stored post of the id when it has no
+// revisions yet, so the content of posts written before revisions existed
+// isn't lost by their first update.
+func ensureFirstRevision(db *gorm.DB, postID uint) error {
+	var count int64
+	if err := db.Model(&PostRevision{}).Where("post_refer = ?", postID).Count(&count).Error; err != nil {
+		return err
+	}
+
+	if count > 0 {
+		return nil
+	}
+
+	var post Post
+	if err := db.Where("id=?", postID).Find(&post).Error; err != nil {
+		return err
+	}
+
+	if post.ID == 0 {
+		return nil
+	}
+
+	return createRevision(db, &post, post.UserRefer)
+}
+
+func (p *Post) GetRevisionsByPostID(db *gorm.DB, postID int, query PageQuery) (*[]PostRevision, *Page, error) {
+	var revisions []PostRevision
+
+	paginate, err := query.Paginate("post_revisions")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := db.Table("post_revisions").Where("post_refer = ?", postID).Scopes(paginate).Find(&revisions).Error; err != nil {
+		return nil, nil, err
+	}
+
+	revisions, page := NextPage(revisions, query, func(r PostRevision) (time.Time, uint) {
+		return r.CreatedAt, r.ID
+	})
+
+	return &revisions, &page, nil
+}
+
+func (p *Post) GetRevision(db *gorm.DB, postID int, number int) (*PostRevision, error) {
+	var revision *PostRevision
+	if err := db.Table("post_revisions").Where("post_refer = ? AND number = ?", postID, number).Find(&revision).Error; err != nil {
+		return nil, err
+	}
+
+	if revision.ID == 0 {
+		return nil, fmt.Errorf("revision %w", ErrNotFound)
+	}
+
+	return revision, nil
+}
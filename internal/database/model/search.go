@@ -0,0 +1,247 @@
+package model
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/pooulad/blogo/internal/render"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	SearchTypePosts = "posts"
+	SearchTypeUsers = "users"
+
+	// ts_headline marks matches with characters of the private use area, the
+	// text is escaped before they are turned into <mark> tags
+	markStart       = "\ue000"
+	markStop        = "\ue001"
+	headlineOptions = `StartSel="` + markStart + `", StopSel="` + markStop + `", MaxWords=35, MinWords=15, MaxFragments=2`
+
+	// snippetLength is the length in runes of snippets without highlights
+	snippetLength = 200
+)
+
+// highlight escapes text for HTML and turns the marks of ts_headline into
+// <mark> tags, so user content never reaches clients as markup.
+func highlight(text string) string {
+	return strings.NewReplacer(markStart, "<mark>", markStop, "</mark>").Replace(html.EscapeString(text))
+}
+
+// PlainSnippet returns the start of the text of rendered HTML, escaped.
+func PlainSnippet(contentHTML string) string {
+	text := []rune(render.PlainText(contentHTML))
+	if len(text) > snippetLength {
+		text = text[:snippetLength]
+	}
+
+	return html.EscapeString(string(text))
+}
+
+// postText is the text of a post without markup. posts rendered before
+// content_html existed fall back to their source.
+const postText = "coalesce(nullif(posts.content_html, ''), posts.content)"
+
+type SearchQuery struct {
+	PageQuery
+	Q    string `form:"q"`
+	Type string `form:"type"`
+}
+
+type PostSearchResult struct {
+	ID          uint       `json:"id"`
+	Title       string     `json:"title"`
+	Headline    string     `json:"headline"`
+	Snippet     string     `json:"snippet"`
+	UserRefer   uint       `json:"user_id"`
+	PublishedAt *time.Time `json:"published_at"`
+	Rank        float64    `json:"rank"`
+}
+
+type UserSearchResult struct {
+	ID        uint    `json:"id"`
+	Username  string  `json:"username"`
+	FirstName string  `json:"first_name,omitempty"`
+	LastName  string  `json:"last_name,omitempty"`
+	Skill     string  `json:"skill"`
+	Snippet   string  `json:"snippet"`
+	Rank      float64 `json:"rank"`
+}
+
+// PrefixTSQuery turns free text into a to_tsquery expression which matches
+// documents containing every word, each as a prefix. everything but letters
+// and digits is dropped, so the result is always a valid tsquery.
+func PrefixTSQuery(q string) string {
+	words := SearchWords(q)
+
+	terms := make([]string, 0, len(words))
+	for _, word := range words {
+		terms = append(terms, word+":*")
+	}
+
+	return strings.Join(terms, " & ")
+}
+
+// SearchWords splits free text into lower case words of letters and digits.
+func SearchWords(q string) []string {
+	words := strings.FieldsFunc(strings.ToLower(q), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	return words
+}
+
+// likeSearch is the fallback of full-text search for databases without
+// tsvector. every word has to be found in one of columns, and the rank counts
+// the words found in rankColumns.
+func likeSearch(db *gorm.DB, q string, columns []string, rankColumns []string) (*gorm.DB, clause.Expr) {
+	var rank []string
+	var rankArgs []interface{}
+	for _, word := range SearchWords(q) {
+		pattern := "%" + word + "%"
+
+		var conditions []string
+		var args []interface{}
+		for _, column := range columns {
+			conditions = append(conditions, fmt.Sprintf("lower(%s) LIKE ?", column))
+			args = append(args, pattern)
+		}
+		db = db.Where("("+strings.Join(conditions, " OR ")+")", args...)
+
+		for _, column := range rankColumns {
+			rank = append(rank, fmt.Sprintf("(CASE WHEN lower(%s) LIKE ? THEN 1 ELSE 0 END)", column))
+			rankArgs = append(rankArgs, pattern)
+		}
+	}
+	if len(rank) == 0 {
+		return db, gorm.Expr("0")
+	}
+
+	return db, gorm.Expr(strings.Join(rank, " + "), rankArgs...)
+}
+
+func (p *Post) SearchPosts(db *gorm.DB, viewerID uint, query SearchQuery) (*[]PostSearchResult, *Page, error) {
+	var results []PostSearchResult
+
+	offset, err := query.Offset()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if db.Dialector.Name() != "postgres" {
+		tx, rank := likeSearch(db.Table("posts"), query.Q, []string{"posts.title", "posts.content"}, []string{"posts.title"})
+		// sqlite can't strip tags, so the start of the markup is fetched and its
+		// text is taken by PlainSnippet. markup is a few times longer than its text
+		if err := tx.
+			Select(
+				"posts.id, posts.title, posts.user_refer, posts.published_at, "+
+					"posts.title AS headline, substr("+postText+", 1, ?) AS snippet, ? AS rank",
+				snippetLength*5,
+				rank,
+			).
+			Where("posts.deleted_at IS NULL").
+			Scopes(VisiblePosts(viewerID)).
+			Order("rank DESC, posts.id DESC").
+			Offset(offset).
+			Limit(query.Limit + 1).
+			Scan(&results).Error; err != nil {
+			return nil, nil, err
+		}
+
+		for i := range results {
+			results[i].Headline = highlight(results[i].Headline)
+			results[i].Snippet = PlainSnippet(results[i].Snippet)
+		}
+
+		results, page := NextOffsetPage(results, query.PageQuery, offset)
+		return &results, &page, nil
+	}
+
+	tsQuery := PrefixTSQuery(query.Q)
+	if err := db.Table("posts, to_tsquery('english', ?) query", tsQuery).
+		Select(
+			"posts.id, posts.title, posts.user_refer, posts.published_at, "+
+				"ts_headline('english', posts.title, query, ?) AS headline, "+
+				"ts_headline('english', regexp_replace("+postText+", '<[^>]*>', ' ', 'g'), query, ?) AS snippet, "+
+				"ts_rank_cd(posts.search_vector, query) AS rank",
+			`HighlightAll=true, StartSel="`+markStart+`", StopSel="`+markStop+`"`, headlineOptions,
+		).
+		Where("posts.search_vector @@ query AND posts.deleted_at IS NULL").
+		Scopes(VisiblePosts(viewerID)).
+		Order("rank DESC, posts.id DESC").
+		Offset(offset).
+		Limit(query.Limit + 1).
+		Scan(&results).Error; err != nil {
+		return nil, nil, err
+	}
+
+	// the tags are gone but their text still has entities, they are decoded so
+	// they aren't escaped twice
+	for i := range results {
+		results[i].Headline = highlight(results[i].Headline)
+		results[i].Snippet = highlight(html.UnescapeString(results[i].Snippet))
+	}
+
+	results, page := NextOffsetPage(results, query.PageQuery, offset)
+	return &results, &page, nil
+}
+
+func (u *User) SearchUsers(db *gorm.DB, query SearchQuery) (*[]UserSearchResult, *Page, error) {
+	var results []UserSearchResult
+
+	offset, err := query.Offset()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if db.Dialector.Name() != "postgres" {
+		tx, rank := likeSearch(
+			db.Table("users"), query.Q,
+			[]string{"users.username", "users.first_name", "users.last_name", "users.skill"},
+			[]string{"users.username", "users.first_name", "users.last_name"},
+		)
+		if err := tx.
+			Select("users.id, users.username, users.first_name, users.last_name, users.skill, users.skill AS snippet, ? AS rank", rank).
+			Where("users.deleted_at IS NULL").
+			Order("rank DESC, users.id DESC").
+			Offset(offset).
+			Limit(query.Limit + 1).
+			Scan(&results).Error; err != nil {
+			return nil, nil, err
+		}
+
+		for i := range results {
+			results[i].Snippet = highlight(results[i].Snippet)
+		}
+
+		results, page := NextOffsetPage(results, query.PageQuery, offset)
+		return &results, &page, nil
+	}
+
+	tsQuery := PrefixTSQuery(query.Q)
+	if err := db.Table("users, to_tsquery('simple', ?) query", tsQuery).
+		Select(
+			"users.id, users.username, users.first_name, users.last_name, users.skill, "+
+				"ts_headline('simple', users.skill, query, ?) AS snippet, "+
+				"ts_rank_cd(users.search_vector, query) AS rank",
+			headlineOptions,
+		).
+		Where("users.search_vector @@ query AND users.deleted_at IS NULL").
+		Order("rank DESC, users.id DESC").
+		Offset(offset).
+		Limit(query.Limit + 1).
+		Scan(&results).Error; err != nil {
+		return nil, nil, err
+	}
+
+	for i := range results {
+		results[i].Snippet = highlight(results[i].Snippet)
+	}
+
+	results, page := NextOffsetPage(results, query.PageQuery, offset)
+	return &results, &page, nil
+}
@@ -0,0 +1,109 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Session is a server side login session. Access tokens carry the session id
+// so revoking the session invalidates every token issued for it.
+type Session struct {
+	gorm.Model
+	UserRefer         uint       `json:"user_id" gorm:"index"`
+	User              User       `json:"-" gorm:"foreignKey:UserRefer"`
+	RefreshTokenHash  string     `json:"-" gorm:"uniqueIndex"`
+	PreviousTokenHash string     `json:"-" gorm:"index"`
+	UserAgent         string     `json:"user_agent"`
+	IP                string     `json:"ip"`
+	ExpiresAt         time.Time  `json:"expires_at"`
+	RevokedAt         *time.Time `json:"revoked_at,omitempty"`
+}
+
+// SessionInput describes the client a session is created for.
+type SessionInput struct {
+	UserID    uint
+	UserAgent string
+	IP        string
+}
+
+// IsActive reports whether the session is neither revoked nor expired.
+func (s *Session) IsActive() bool {
+	return s.ID != 0 && s.RevokedAt == nil && time.Now().Before(s.ExpiresAt)
+}
+
+func (s *Session) CreateSession(db *gorm.DB, sessionBody *Session) error {
+	if err := db.Create(&sessionBody).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Session) GetSessionByID(db *gorm.DB, sessionID uint) (*Session, error) {
+	var session *Session
+	if err := db.Table("sessions").Where("id=?", sessionID).Find(&session).Error; err != nil {
+		return nil, err
+	}
+
+	if session.ID == 0 {
+		return nil, fmt.Errorf("session %w", ErrNotFound)
+	}
+
+	return session, nil
+}
+
+func (s *Session) GetSessionByTokenHash(db *gorm.DB, tokenHash string) (*Session, error) {
+	var session *Session
+	if err := db.Table("sessions").Where("refresh_token_hash=?", tokenHash).Preload("User").Find(&session).Error; err != nil {
+		return nil, err
+	}
+
+	if session.ID == 0 {
+		return nil, fmt.Errorf("session %w", ErrNotFound)
+	}
+
+	return session, nil
+}
+
+func (s *Session) GetSessionByPreviousTokenHash(db *gorm.DB, tokenHash string) (*Session, error) {
+	var session *Session
+	if err := db.Table("sessions").Where("previous_token_hash=?", tokenHash).Find(&session).Error; err != nil {
+		return nil, err
+	}
+
+	if session.ID == 0 {
+		return nil, fmt.Errorf("session %w", ErrNotFound)
+	}
+
+	return session, nil
+}
+
+// RotateRefreshToken replaces the refresh token of the session and keeps the
+// old hash around so a replayed token can be detected.
+func (s *Session) RotateRefreshToken(db *gorm.DB, sessionBody *Session, tokenHash string, expiresAt time.Time) error {
+	result := db.Model(&Session{}).Where("id=? AND refresh_token_hash=?", sessionBody.ID, sessionBody.RefreshTokenHash).Updates(map[string]interface{}{
+		"previous_token_hash": sessionBody.RefreshTokenHash,
+		"refresh_token_hash":  tokenHash,
+		"expires_at":          expiresAt,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	// another request rotated the token first
+	if result.RowsAffected == 0 {
+		return ErrTokenReused
+	}
+
+	return nil
+}
+
+func (s *Session) RevokeSessionByID(db *gorm.DB, sessionID uint) error {
+	return db.Model(&Session{}).Where("id=? AND revoked_at IS NULL", sessionID).Update("revoked_at", time.Now()).Error
+}
+
+func (s *Session) RevokeSessionsByUserID(db *gorm.DB, userID uint) error {
+	return db.Model(&Session{}).Where("user_refer=? AND revoked_at IS NULL", userID).Update("revoked_at", time.Now()).Error
+}
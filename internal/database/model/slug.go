@@ -0,0 +1,111 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pooulad/blogo/utilities"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PostSlug is a slug a post had before it was renamed. old links keep
+// working by redirecting to the current slug of the post.
+type PostSlug struct {
+	Slug      string    `json:"slug" gorm:"primarykey"`
+	PostRefer uint      `json:"post_id" gorm:"index"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (p *Post) GetPostBySlug(db *gorm.DB, slug string) (*Post, error) {
+	var post *Post
+	if err := db.Table("posts").Where("slug=?", slug).Find(&post).Error; err != nil {
+		return nil, err
+	}
+
+	if post.ID == 0 {
+		return nil, fmt.Errorf("post %w", ErrNotFound)
+	}
+
+	return post, nil
+}
+
+// GetPostByOldSlug returns the post which had the slug before a rename.
+func (p *Post) GetPostByOldSlug(db *gorm.DB, slug string) (*Post, error) {
+	var postSlug PostSlug
+	if err := db.Where("slug=?", slug).Find(&postSlug).Error; err != nil {
+		return nil, err
+	}
+
+	if postSlug.PostRefer == 0 {
+		return nil, fmt.Errorf("post %w", ErrNotFound)
+	}
+
+	return p.GetPostByID(db, int(postSlug.PostRefer))
+}
+
+// IsSlugTaken reports whether another post uses the slug now or used it
+// before, since old slugs must keep redirecting to their post.
+func (p *Post) IsSlugTaken(db *gorm.DB, slug string, postID uint) (bool, error) {
+	var count int64
+	if err := db.Unscoped().Model(&Post{}).Where("slug = ? AND id <> ?", slug, postID).Count(&count).Error; err != nil {
+		return false, err
+	}
+
+	if count > 0 {
+		return true, nil
+	}
+
+	if err := db.Model(&PostSlug{}).Where("slug = ? AND post_refer <> ?", slug, postID).Count(&count).Error; err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// UniquePostSlug slugifies the text and adds a numeric suffix until the slug
+// isn't taken by another post.
+func (p *Post) UniquePostSlug(db *gorm.DB, text string, postID uint) (string, error) {
+	base := utilities.Slugify(text)
+	if base == "" {
+		base = "post"
+	}
+
+	slug := base
+	for i := 2; ; i++ {
+		taken, err := p.IsSlugTaken(db, slug, postID)
+		if err != nil {
+			return "", err
+		}
+
+		if !taken {
+			return slug, nil
+		}
+
+		slug = fmt.Sprintf("%s-%d", base, i)
+	}
+}
+
+// keepOldSlug moves the stored slug of the post to the slug history when
+// the post is saved with another one. it runs in the transaction which saves
+// the post, so the history never lags behind the slug.
+func keepOldSlug(tx *gorm.DB, postBody *Post) error {
+	var stored Post
+	if err := tx.Unscoped().Select("slug").Where("id=?", postBody.ID).Take(&stored).Error; err != nil {
+		return err
+	}
+
+	if stored.Slug == postBody.Slug {
+		return nil
+	}
+
+	if stored.Slug != "" {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&PostSlug{Slug: stored.Slug, PostRefer: postBody.ID}).Error; err != nil {
+			return err
+		}
+	}
+
+	// the post may take back one of its own old slugs
+	return tx.Where("slug = ? AND post_refer = ?", postBody.Slug, postBody.ID).Delete(&PostSlug{}).Error
+}
@@ -0,0 +1,166 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pooulad/blogo/utilities"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Tag struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	Name      string    `json:"name"`
+	Slug      string    `json:"slug" gorm:"uniqueIndex"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TagAlias keeps the slug of a merged tag, so tagging a post with the old
+// name keeps resolving to the tag it was merged into.
+type TagAlias struct {
+	Slug     string `json:"slug" gorm:"primarykey"`
+	TagRefer uint   `json:"tag_id" gorm:"index"`
+}
+
+type TagCount struct {
+	Name  string `json:"name"`
+	Slug  string `json:"slug"`
+	Count int64  `json:"count"`
+}
+
+type TagsInput struct {
+	Tags []string `json:"tags"`
+}
+
+type MergeTagsInput struct {
+	Into string `json:"into"`
+}
+
+// ResolveSlug normalises the name and follows a merged tag's alias to the
+// slug of the tag it was merged into.
+func (t *Tag) ResolveSlug(db *gorm.DB, name string) (string, error) {
+	slug := utilities.Slugify(name)
+
+	var alias TagAlias
+	if err := db.Where("slug=?", slug).Find(&alias).Error; err != nil {
+		return "", err
+	}
+
+	if alias.TagRefer == 0 {
+		return slug, nil
+	}
+
+	var tag Tag
+	if err := db.Where("id=?", alias.TagRefer).Find(&tag).Error; err != nil {
+		return "", err
+	}
+
+	if tag.ID == 0 {
+		return slug, nil
+	}
+
+	return tag.Slug, nil
+}
+
+func (t *Tag) GetTagBySlug(db *gorm.DB, slug string) (*Tag, error) {
+	var tag *Tag
+	if err := db.Table("tags").Where("slug=?", slug).Find(&tag).Error; err != nil {
+		return nil, err
+	}
+
+	if tag.ID == 0 {
+		return nil, fmt.Errorf("tag %w", ErrNotFound)
+	}
+
+	return tag, nil
+}
+
+// FindOrCreateTags returns the tags with the given names, creating the
+// ones which don't exist yet.
+func (t *Tag) FindOrCreateTags(db *gorm.DB, names []string) ([]Tag, error) {
+	var tags []Tag
+	seen := map[string]bool{}
+
+	for _, name := range names {
+		slug, err := t.ResolveSlug(db, name)
+		if err != nil {
+			return nil, err
+		}
+
+		if slug == "" || seen[slug] {
+			continue
+		}
+		seen[slug] = true
+
+		tag := Tag{Name: name, Slug: slug}
+		if err := db.Where(Tag{Slug: slug}).FirstOrCreate(&tag).Error; err != nil {
+			return nil, err
+		}
+
+		tags = append(tags, tag)
+	}
+
+	return tags, nil
+}
+
+func (t *Tag) AttachTags(db *gorm.DB, postID uint, tags []Tag) error {
+	for _, tag := range tags {
+		if err := db.Table("post_tags").Clauses(clause.OnConflict{DoNothing: true}).Create(map[string]interface{}{
+			"post_id": postID,
+			"tag_id":  tag.ID,
+		}).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (t *Tag) DetachTag(db *gorm.DB, postID uint, tagID uint) error {
+	return db.Table("post_tags").Where("post_id = ? AND tag_id = ?", postID, tagID).Delete(nil).Error
+}
+
+// GetTagCounts counts the published posts of every tag for a tag cloud.
+func (t *Tag) GetTagCounts(db *gorm.DB) (*[]TagCount, error) {
+	var counts []TagCount
+	if err := db.Table("tags").
+		Select("tags.name, tags.slug, count(posts.id) AS count").
+		Joins("JOIN post_tags ON post_tags.tag_id = tags.id").
+		Joins("JOIN posts ON posts.id = post_tags.post_id AND posts.deleted_at IS NULL AND posts.status = ?", PostStatusPublished).
+		Group("tags.id, tags.name, tags.slug").
+		Order("count DESC, tags.slug").
+		Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+
+	return &counts, nil
+}
+
+// MergeTags moves every post of from to into, deletes from and keeps its
+// slug as an alias of into.
+func (t *Tag) MergeTags(db *gorm.DB, from, into *Tag) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(
+			"INSERT INTO post_tags (post_id, tag_id) SELECT post_id, ? FROM post_tags WHERE tag_id = ? ON CONFLICT DO NOTHING",
+			into.ID, from.ID,
+		).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Table("post_tags").Where("tag_id = ?", from.ID).Delete(nil).Error; err != nil {
+			return err
+		}
+
+		// aliases of the merged tag follow it into the new tag
+		if err := tx.Model(&TagAlias{}).Where("tag_refer = ?", from.ID).Update("tag_refer", into.ID).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Save(&TagAlias{Slug: from.Slug, TagRefer: into.ID}).Error; err != nil {
+			return err
+		}
+
+		return tx.Delete(&Tag{}, from.ID).Error
+	})
+}
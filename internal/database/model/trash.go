@@ -0,0 +1,216 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// trashed scopes an unscoped query to the soft deleted rows of table.
+func trashed(table string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Unscoped().Where(fmt.Sprintf("%s.deleted_at IS NOT NULL", table))
+	}
+}
+
+func (p *Post) GetTrashedPosts(db *gorm.DB, query PageQuery) (*[]Post, *Page, error) {
+	var posts []Post
+
+	paginate, err := query.Paginate("posts")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := db.Table("posts").Scopes(trashed("posts"), paginate).Find(&posts).Error; err != nil {
+		return nil, nil, err
+	}
+
+	posts, page := NextPage(posts, query, func(post Post) (time.Time, uint) {
+		return post.CreatedAt, post.ID
+	})
+
+	return &posts, &page, nil
+}
+
+func (p *Post) GetTrashedPostByID(db *gorm.DB, postID int) (*Post, error) {
+	var post *Post
+	if err := db.Table("posts").Scopes(trashed("posts")).Where("id=?", postID).Find(&post).Error; err != nil {
+		return nil, err
+	}
+
+	if post.ID == 0 {
+		return nil, fmt.Errorf("post %w in trash", ErrNotFound)
+	}
+
+	return post, nil
+}
+
+func (p *Post) RestorePostByID(db *gorm.DB, postID int) error {
+	return db.Unscoped().Model(&Post{}).Where("id=?", postID).Update("deleted_at", nil).Error
+}
+
+// PurgePostByID deletes the post for good, together with every row which
+// refers to it.
+func (p *Post) PurgePostByID(db *gorm.DB, postID int) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		return purgePosts(tx, []uint{uint(postID)})
+	})
+}
+
+// PurgeExpiredPosts purges the posts trashed before the given time and
+// returns how many posts were purged.
+func (p *Post) PurgeExpiredPosts(db *gorm.DB, before time.Time) (int64, error) {
+	var postIDs []uint
+	if err := db.Unscoped().Model(&Post{}).Where("deleted_at < ?", before).Pluck("id", &postIDs).Error; err != nil {
+		return 0, err
+	}
+
+	if len(postIDs) == 0 {
+		return 0, nil
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return purgePosts(tx, postIDs)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(len(postIDs)), nil
+}
+
+// purgePosts hard deletes the posts. the join tables and the tables which
+// refer to posts are cleaned up first, so no row is left pointing at them.
+func purgePosts(tx *gorm.DB, postIDs []uint) error {
+	for _, table := range []string{"likes", "post_tags", "post_categories"} {
+		if err := tx.Table(table).Where("post_id IN ?", postIDs).Delete(nil).Error; err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Unscoped().Where("post_refer IN ?", postIDs).Delete(&Comment{}).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Where("post_refer IN ?", postIDs).Delete(&PostRevision{}).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Where("post_refer IN ?", postIDs).Delete(&PostSlug{}).Error; err != nil {
+		return err
+	}
+
+	return tx.Unscoped().Where("id IN ?", postIDs).Delete(&Post{}).Error
+}
+
+func (u *User) GetTrashedUsers(db *gorm.DB, query PageQuery) (*[]UserResponse, *Page, error) {
+	var users []UserResponse
+
+	paginate, err := query.Paginate("users")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tx := db.Table("users").Select("id", "created_at", "updated_at", "deleted_at", "first_name", "last_name", "username", "email", "role", "skill")
+	if err := tx.Scopes(trashed("users"), paginate).Find(&users).Error; err != nil {
+		return nil, nil, err
+	}
+
+	users, page := NextPage(users, query, func(user UserResponse) (time.Time, uint) {
+		return user.CreatedAt, user.ID
+	})
+
+	return &users, &page, nil
+}
+
+func (u *User) GetTrashedUserByID(db *gorm.DB, userID int) (*User, error) {
+	var user *User
+	if err := db.Table("users").Scopes(trashed("users")).Where("id=?", userID).Find(&user).Error; err != nil {
+		return nil, err
+	}
+
+	if user.ID == 0 {
+		return nil, fmt.Errorf("user %w in trash", ErrNotFound)
+	}
+
+	return user, nil
+}
+
+// RestoreUserByID restores the user and the posts which were trashed with
+// it, which DeleteUserByID stamped with the deleted_at of the user. posts the
+// user had trashed before are left in the trash.
+func (u *User) RestoreUserByID(db *gorm.DB, userBody *User) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&Post{}).Where("user_refer = ? AND deleted_at = ?", userBody.ID, userBody.DeletedAt.Time).Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+
+		return tx.Unscoped().Model(&User{}).Where("id=?", userBody.ID).Update("deleted_at", nil).Error
+	})
+}
+
+// PurgeUserByID deletes the user for good with its posts, likes, follows and
+// sessions. comments on posts of other users keep their place in the thread
+// but lose their content and author.
+func (u *User) PurgeUserByID(db *gorm.DB, userID int) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		return purgeUser(tx, uint(userID))
+	})
+}
+
+// PurgeExpiredUsers purges the users trashed before the given time and
+// returns how many users were purged.
+func (u *User) PurgeExpiredUsers(db *gorm.DB, before time.Time) (int64, error) {
+	var userIDs []uint
+	if err := db.Unscoped().Model(&User{}).Where("deleted_at < ?", before).Pluck("id", &userIDs).Error; err != nil {
+		return 0, err
+	}
+
+	for _, userID := range userIDs {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			return purgeUser(tx, userID)
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return int64(len(userIDs)), nil
+}
+
+func purgeUser(tx *gorm.DB, userID uint) error {
+	var postIDs []uint
+	if err := tx.Unscoped().Model(&Post{}).Where("user_refer = ?", userID).Pluck("id", &postIDs).Error; err != nil {
+		return err
+	}
+
+	if len(postIDs) > 0 {
+		if err := purgePosts(tx, postIDs); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Table("likes").Where("user_id = ?", userID).Delete(nil).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Table("user_follows").Where("follower_id = ? OR followed_id = ?", userID, userID).Delete(nil).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Unscoped().Where("user_refer = ?", userID).Delete(&Session{}).Error; err != nil {
+		return err
+	}
+
+	err := tx.Unscoped().Model(&Comment{}).Where("user_refer = ?", userID).Updates(map[string]interface{}{
+		"content":    "",
+		"user_refer": 0,
+		"deleted_at": gorm.Expr("COALESCE(deleted_at, ?)", time.Now()),
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	return tx.Unscoped().Where("id = ?", userID).Delete(&User{}).Error
+}
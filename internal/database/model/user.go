@@ -1,22 +1,31 @@
 package model
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	RoleAdmin  = "admin"
+	RoleEditor = "editor"
+	RoleAuthor = "author"
+	RoleUser   = "user"
 )
 
 type User struct {
 	gorm.Model
-	FirstName   string    `json:"first_name,omitempty"`
-	LastName    string    `json:"last_name,omitempty"`
-	Username    string    `json:"username"`
-	Password    string    `json:"password"`
-	Email       string    `json:"email"`
+	FirstName   string    `json:"first_name,omitempty" binding:"max=50"`
+	LastName    string    `json:"last_name,omitempty" binding:"max=50"`
+	Username    string    `json:"username" binding:"required,username"`
+	Password    string    `json:"password,omitempty" binding:"required,password"`
+	Email       string    `json:"email" binding:"required,email"`
 	Role        string    `json:"role"`
 	Active      bool      `json:"active"`
-	Skill       string    `json:"skill"`
+	Skill       string    `json:"skill" binding:"max=200"`
 	LastVisited time.Time `json:"last_visited,omitempty"`
 	Posts       []Post    `json:"posts" gorm:"foreignKey:UserRefer"`
 	LikedPosts  []Post    `gorm:"many2many:likes;"`
@@ -38,6 +47,25 @@ type UserResponse struct {
 	Following   []UserResponse `gorm:"many2many:user_follows;joinForeignKey:FollowerID;joinReferences:FollowedID" json:"following,omitempty"`
 }
 
+// UpdateUserInput holds the fields of a user update. fields left out of the
+// request are nil and keep their value.
+type UpdateUserInput struct {
+	FirstName *string `json:"first_name" binding:"omitnil,max=50"`
+	LastName  *string `json:"last_name" binding:"omitnil,max=50"`
+	Email     *string `json:"email" binding:"omitnil,email"`
+	Skill     *string `json:"skill" binding:"omitnil,max=200"`
+	Password  *string `json:"password" binding:"omitnil,password"`
+	Role      *string `json:"role"`
+	Active    *bool   `json:"active"`
+}
+
+// UserQuery filters and paginates the user list.
+type UserQuery struct {
+	PageQuery
+	Role   string `form:"role"`
+	Active *bool  `form:"active"`
+}
+
 func (u *User) CreateUser(db *gorm.DB, userBody *User) error {
 	if err := db.Create(&userBody).Error; err != nil {
 		return err
@@ -47,13 +75,17 @@ func (u *User) CreateUser(db *gorm.DB, userBody *User) error {
 }
 
 func (u *User) UpdateUserByID(db *gorm.DB, userBody *User) error {
-	if err := db.Save(&userBody).Error; err != nil {
+	// posts and follows are changed by their own methods, never by a save
+	if err := db.Omit(clause.Associations).Save(&userBody).Error; err != nil {
 		return err
 	}
 
 	return nil
 }
 
+// DeleteUserByID moves the user to the trash with its posts. they share one
+// deleted_at, so RestoreUserByID can tell them from the posts which were
+// trashed before.
 func (u *User) DeleteUserByID(db *gorm.DB, userID int) error {
 	var user *User
 	if err := db.Table("users").Where("id=?", userID).Find(&user).Error; err != nil {
@@ -61,36 +93,59 @@ func (u *User) DeleteUserByID(db *gorm.DB, userID int) error {
 	}
 
 	if user.ID == 0 {
-		return fmt.Errorf("user not found")
+		return fmt.Errorf("user %w", ErrNotFound)
 	}
 
-	if err := db.Table("users").Select("Posts").Delete(&user).Error; err != nil {
-		return err
-	}
+	// postgres keeps microseconds, the stamp has to read back unchanged
+	deletedAt := db.NowFunc().Truncate(time.Microsecond)
 
-	return nil
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&Post{}).Where("user_refer = ?", user.ID).UpdateColumn("deleted_at", deletedAt).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&User{}).Where("id = ?", user.ID).UpdateColumn("deleted_at", deletedAt).Error
+	})
 }
 
-func (u *User) GetUserByID(db *gorm.DB, userID int) (*User, error) {
+func (u *User) GetUserByID(db *gorm.DB, userID int, viewerID uint) (*User, error) {
 	var user *User
-	if err := db.Table("users").Where("id=?", userID).Preload("Posts").Find(&user).Error; err != nil {
+	if err := db.Table("users").Where("id=?", userID).Preload("Posts", VisiblePosts(viewerID)).Find(&user).Error; err != nil {
 		return nil, err
 	}
 
 	if user.ID == 0 {
-		return nil, fmt.Errorf("user not found")
+		return nil, fmt.Errorf("user %w", ErrNotFound)
 	}
 
 	return user, nil
 }
 
-func (u *UserResponse) GetAllUsers(db *gorm.DB) (*[]UserResponse, error) {
-	var users *[]UserResponse
-	if err := db.Table("users").Select("id", "first_name", "last_name", "username", "email", "role", "skill").Preload("Posts").Find(&users).Error; err != nil {
-		return nil, err
+func (u *UserResponse) GetAllUsers(db *gorm.DB, viewerID uint, query UserQuery) (*[]UserResponse, *Page, error) {
+	var users []UserResponse
+
+	paginate, err := query.Paginate("users")
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return users, nil
+	tx := db.Table("users").Select("id", "created_at", "first_name", "last_name", "username", "email", "role", "skill").Scopes(paginate)
+	if query.Role != "" {
+		tx = tx.Where("users.role = ?", query.Role)
+	}
+	if query.Active != nil {
+		tx = tx.Where("users.active = ?", *query.Active)
+	}
+
+	if err := tx.Preload("Posts", VisiblePosts(viewerID)).Find(&users).Error; err != nil {
+		return nil, nil, err
+	}
+
+	users, page := NextPage(users, query.PageQuery, func(user UserResponse) (time.Time, uint) {
+		return user.CreatedAt, user.ID
+	})
+
+	return &users, &page, nil
 }
 
 func (u *User) IsUserExistByUsername(db *gorm.DB, userBody *User) (bool, error) {
@@ -112,22 +167,64 @@ func (u *User) FollowUserByID(db *gorm.DB, followerID, followedID uint) error {
 	}).Error
 }
 
+func (u *User) IsFollowing(db *gorm.DB, followerID, followedID uint) (bool, error) {
+	var count int64
+	if err := db.Table("user_follows").Where("follower_id = ? AND followed_id = ?", followerID, followedID).Count(&count).Error; err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
 func (u *User) UnFollowUserByID(db *gorm.DB, followerID, followedID uint) error {
 	return db.Table("user_follows").Where("follower_id = ? AND followed_id = ?", followerID, followedID).Delete(nil).Error
 }
 
-func (s *User) GetFollowers(db *gorm.DB, userID int) (*[]UserResponse, error) {
-	var user User
+func (u *User) GetFollowers(db *gorm.DB, userID int, viewerID uint, query PageQuery) (*[]UserResponse, *Page, error) {
+	return getFollowUsers(db, userID, viewerID, query, "user_follows.follower_id", "user_follows.followed_id")
+}
+
+func (u *User) GetFollowings(db *gorm.DB, userID int, viewerID uint, query PageQuery) (*[]UserResponse, *Page, error) {
+	return getFollowUsers(db, userID, viewerID, query, "user_follows.followed_id", "user_follows.follower_id")
+}
+
+// getFollowUsers pages through the users on one side of user_follows whose
+// other side is userID.
+func getFollowUsers(db *gorm.DB, userID int, viewerID uint, query PageQuery, joinColumn, whereColumn string) (*[]UserResponse, *Page, error) {
+	var users []User
 	var response []UserResponse
 
-	if err := db.Table("users").Preload("Followers.Posts").First(&user, userID).Error; err != nil {
-		return nil, err
+	if err := db.Table("users").First(&User{}, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, fmt.Errorf("user %w", ErrNotFound)
+		}
+		return nil, nil, err
+	}
+
+	paginate, err := query.Paginate("users")
+	if err != nil {
+		return nil, nil, err
 	}
 
-	for _, user := range user.Followers {
+	if err := db.Table("users").
+		Select("users.*").
+		Joins(fmt.Sprintf("JOIN user_follows ON %s = users.id", joinColumn)).
+		Where(fmt.Sprintf("%s = ?", whereColumn), userID).
+		Scopes(paginate).
+		Preload("Posts", VisiblePosts(viewerID)).
+		Find(&users).Error; err != nil {
+		return nil, nil, err
+	}
+
+	users, page := NextPage(users, query, func(user User) (time.Time, uint) {
+		return user.CreatedAt, user.ID
+	})
+
+	for _, user := range users {
 		var userResponse UserResponse
 
 		userResponse.ID = user.ID
+		userResponse.CreatedAt = user.CreatedAt
 		userResponse.FirstName = user.FirstName
 		userResponse.LastName = user.LastName
 		userResponse.Username = user.Username
@@ -139,31 +236,22 @@ func (s *User) GetFollowers(db *gorm.DB, userID int) (*[]UserResponse, error) {
 		response = append(response, userResponse)
 	}
 
-	return &response, nil
+	return &response, &page, nil
 }
 
-func (u *User) GetFollowings(db *gorm.DB, userID int) (*[]UserResponse, error) {
-	var user User
-	var response []UserResponse
-
-	if err := db.Table("users").Preload("Following.Posts").First(&user, userID).Error; err != nil {
-		return nil, err
+// GetUsernamesByIDs maps the id of every given user to its username.
+func (u *User) GetUsernamesByIDs(db *gorm.DB, userIDs []uint) (map[uint]string, error) {
+	var users []User
+	if len(userIDs) > 0 {
+		if err := db.Select("id", "username").Where("id IN ?", userIDs).Find(&users).Error; err != nil {
+			return nil, err
+		}
 	}
 
-	for _, user := range user.Following {
-		var userResponse UserResponse
-
-		userResponse.ID = user.ID
-		userResponse.FirstName = user.FirstName
-		userResponse.LastName = user.LastName
-		userResponse.Username = user.Username
-		userResponse.Email = user.Email
-		userResponse.Skill = user.Skill
-		userResponse.Role = user.Role
-		userResponse.Posts = user.Posts
-
-		response = append(response, userResponse)
+	usernames := make(map[uint]string, len(users))
+	for _, user := range users {
+		usernames[user.ID] = user.Username
 	}
 
-	return &response, nil
+	return usernames, nil
 }
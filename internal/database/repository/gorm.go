@@ -0,0 +1,429 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pooulad/blogo/internal/database/model"
+	"gorm.io/gorm"
+)
+
+// NewGorm returns the repositories which store everything with GORM.
+func NewGorm(db *gorm.DB) Repositories {
+	return Repositories{
+		Users:      &gormUsers{db: db},
+		Posts:      &gormPosts{db: db},
+		Follows:    &gormFollows{db: db},
+		Comments:   &gormComments{db: db},
+		Tags:       &gormTags{db: db},
+		Categories: &gormCategories{db: db},
+		Sessions:   &gormSessions{db: db},
+		Trash:      &gormTrash{db: db},
+	}
+}
+
+type gormUsers struct {
+	db    *gorm.DB
+	model model.User
+}
+
+func (r *gormUsers) Create(ctx context.Context, user *model.User) error {
+	return r.model.CreateUser(r.db.WithContext(ctx), user)
+}
+
+func (r *gormUsers) GetByID(ctx context.Context, userID, viewerID uint) (*model.User, error) {
+	return r.model.GetUserByID(r.db.WithContext(ctx), int(userID), viewerID)
+}
+
+func (r *gormUsers) GetByUsername(ctx context.Context, username string) (*model.User, error) {
+	var user model.User
+	if err := r.db.WithContext(ctx).Where("username = ?", username).Find(&user).Error; err != nil {
+		return nil, err
+	}
+
+	if user.ID == 0 {
+		return nil, fmt.Errorf("user %w", ErrNotFound)
+	}
+
+	return &user, nil
+}
+
+func (r *gormUsers) IsEmailTaken(ctx context.Context, email string, exceptUserID uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.User{}).
+		Where("lower(email) = lower(?) AND id <> ?", email, exceptUserID).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (r *gormUsers) Update(ctx context.Context, user *model.User) error {
+	return r.model.UpdateUserByID(r.db.WithContext(ctx), user)
+}
+
+func (r *gormUsers) Delete(ctx context.Context, userID uint) error {
+	return r.model.DeleteUserByID(r.db.WithContext(ctx), int(userID))
+}
+
+func (r *gormUsers) List(ctx context.Context, viewerID uint, query model.UserQuery) ([]model.UserResponse, *model.Page, error) {
+	var userResponse model.UserResponse
+	users, page, err := userResponse.GetAllUsers(r.db.WithContext(ctx), viewerID, query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return *users, page, nil
+}
+
+func (r *gormUsers) Usernames(ctx context.Context, userIDs []uint) (map[uint]string, error) {
+	return r.model.GetUsernamesByIDs(r.db.WithContext(ctx), userIDs)
+}
+
+func (r *gormUsers) Search(ctx context.Context, query model.SearchQuery) ([]model.UserSearchResult, *model.Page, error) {
+	results, page, err := r.model.SearchUsers(r.db.WithContext(ctx), query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return *results, page, nil
+}
+
+type gormPosts struct {
+	db    *gorm.DB
+	model model.Post
+}
+
+func (r *gormPosts) Create(ctx context.Context, post *model.Post) error {
+	return r.model.CreatePost(r.db.WithContext(ctx), post)
+}
+
+func (r *gormPosts) GetByID(ctx context.Context, postID uint) (*model.Post, error) {
+	return r.model.GetPostByID(r.db.WithContext(ctx), int(postID))
+}
+
+func (r *gormPosts) GetBySlug(ctx context.Context, slug string) (*model.Post, error) {
+	return r.model.GetPostBySlug(r.db.WithContext(ctx), slug)
+}
+
+func (r *gormPosts) GetByOldSlug(ctx context.Context, slug string) (*model.Post, error) {
+	return r.model.GetPostByOldSlug(r.db.WithContext(ctx), slug)
+}
+
+func (r *gormPosts) IsSlugTaken(ctx context.Context, slug string, exceptPostID uint) (bool, error) {
+	return r.model.IsSlugTaken(r.db.WithContext(ctx), slug, exceptPostID)
+}
+
+func (r *gormPosts) UniqueSlug(ctx context.Context, text string, exceptPostID uint) (string, error) {
+	return r.model.UniquePostSlug(r.db.WithContext(ctx), text, exceptPostID)
+}
+
+func (r *gormPosts) Update(ctx context.Context, post *model.Post, editorID uint) error {
+	return r.model.UpdatePostByID(r.db.WithContext(ctx), post, editorID)
+}
+
+func (r *gormPosts) Transfer(ctx context.Context, postID, userID uint) error {
+	return r.model.TransferPost(r.db.WithContext(ctx), postID, userID)
+}
+
+func (r *gormPosts) Delete(ctx context.Context, postID uint) error {
+	return r.model.DeletePostByID(r.db.WithContext(ctx), int(postID))
+}
+
+func (r *gormPosts) List(ctx context.Context, viewerID uint, query model.PostQuery) ([]model.Post, *model.Page, error) {
+	posts, page, err := r.model.GetAllPosts(r.db.WithContext(ctx), viewerID, query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return *posts, page, nil
+}
+
+func (r *gormPosts) HomeFeed(ctx context.Context, userID uint, query model.PageQuery) ([]model.Post, *model.Page, error) {
+	posts, page, err := r.model.GetHomeFeed(r.db.WithContext(ctx), userID, query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return *posts, page, nil
+}
+
+func (r *gormPosts) Feed(ctx context.Context, query model.FeedQuery, limit int) ([]model.Post, error) {
+	posts, err := r.model.GetFeedPosts(r.db.WithContext(ctx), query, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return *posts, nil
+}
+
+func (r *gormPosts) Search(ctx context.Context, viewerID uint, query model.SearchQuery) ([]model.PostSearchResult, *model.Page, error) {
+	results, page, err := r.model.SearchPosts(r.db.WithContext(ctx), viewerID, query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return *results, page, nil
+}
+
+func (r *gormPosts) Like(ctx context.Context, userID, postID uint) error {
+	return r.model.LikePostByID(r.db.WithContext(ctx), userID, postID)
+}
+
+func (r *gormPosts) Unlike(ctx context.Context, userID, postID uint) error {
+	return r.model.UnlikePostByID(r.db.WithContext(ctx), userID, postID)
+}
+
+func (r *gormPosts) IsLiked(ctx context.Context, userID, postID uint) (bool, error) {
+	return r.model.IsPostLiked(r.db.WithContext(ctx), userID, postID)
+}
+
+func (r *gormPosts) LikeStats(ctx context.Context, viewerID uint, postIDs []uint) (map[uint]model.LikeStats, error) {
+	return r.model.GetLikeStats(r.db.WithContext(ctx), viewerID, postIDs)
+}
+
+func (r *gormPosts) PublishScheduled(ctx context.Context, now time.Time) (int64, error) {
+	return r.model.PublishScheduledPosts(r.db.WithContext(ctx), now)
+}
+
+func (r *gormPosts) Revisions(ctx context.Context, postID uint, query model.PageQuery) ([]model.PostRevision, *model.Page, error) {
+	revisions, page, err := r.model.GetRevisionsByPostID(r.db.WithContext(ctx), int(postID), query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return *revisions, page, nil
+}
+
+func (r *gormPosts) Revision(ctx context.Context, postID uint, number int) (*model.PostRevision, error) {
+	return r.model.GetRevision(r.db.WithContext(ctx), int(postID), number)
+}
+
+type gormFollows struct {
+	db    *gorm.DB
+	model model.User
+}
+
+func (r *gormFollows) Follow(ctx context.Context, followerID, followedID uint) error {
+	return r.model.FollowUserByID(r.db.WithContext(ctx), followerID, followedID)
+}
+
+func (r *gormFollows) Unfollow(ctx context.Context, followerID, followedID uint) error {
+	return r.model.UnFollowUserByID(r.db.WithContext(ctx), followerID, followedID)
+}
+
+func (r *gormFollows) IsFollowing(ctx context.Context, followerID, followedID uint) (bool, error) {
+	return r.model.IsFollowing(r.db.WithContext(ctx), followerID, followedID)
+}
+
+func (r *gormFollows) Followers(ctx context.Context, userID, viewerID uint, query model.PageQuery) ([]model.UserResponse, *model.Page, error) {
+	users, page, err := r.model.GetFollowers(r.db.WithContext(ctx), int(userID), viewerID, query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return *users, page, nil
+}
+
+func (r *gormFollows) Following(ctx context.Context, userID, viewerID uint, query model.PageQuery) ([]model.UserResponse, *model.Page, error) {
+	users, page, err := r.model.GetFollowings(r.db.WithContext(ctx), int(userID), viewerID, query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return *users, page, nil
+}
+
+type gormComments struct {
+	db    *gorm.DB
+	model model.Comment
+}
+
+func (r *gormComments) Create(ctx context.Context, comment *model.Comment) error {
+	return r.model.CreateComment(r.db.WithContext(ctx), comment)
+}
+
+func (r *gormComments) GetByID(ctx context.Context, commentID uint) (*model.Comment, error) {
+	return r.model.GetCommentByID(r.db.WithContext(ctx), int(commentID))
+}
+
+func (r *gormComments) ListByPostID(ctx context.Context, postID uint) ([]model.Comment, error) {
+	comments, err := r.model.GetCommentsByPostID(r.db.WithContext(ctx), postID)
+	if err != nil {
+		return nil, err
+	}
+
+	return *comments, nil
+}
+
+func (r *gormComments) Update(ctx context.Context, comment *model.Comment) error {
+	return r.model.UpdateCommentByID(r.db.WithContext(ctx), comment)
+}
+
+func (r *gormComments) Delete(ctx context.Context, commentID uint) error {
+	return r.model.DeleteCommentByID(r.db.WithContext(ctx), commentID)
+}
+
+func (r *gormComments) CountByPostIDs(ctx context.Context, postIDs []uint) (map[uint]int64, error) {
+	return r.model.CountCommentsByPostIDs(r.db.WithContext(ctx), postIDs)
+}
+
+type gormTags struct {
+	db    *gorm.DB
+	model model.Tag
+}
+
+func (r *gormTags) ResolveSlug(ctx context.Context, name string) (string, error) {
+	return r.model.ResolveSlug(r.db.WithContext(ctx), name)
+}
+
+func (r *gormTags) GetBySlug(ctx context.Context, slug string) (*model.Tag, error) {
+	return r.model.GetTagBySlug(r.db.WithContext(ctx), slug)
+}
+
+func (r *gormTags) FindOrCreate(ctx context.Context, names []string) ([]model.Tag, error) {
+	return r.model.FindOrCreateTags(r.db.WithContext(ctx), names)
+}
+
+func (r *gormTags) Attach(ctx context.Context, postID uint, tags []model.Tag) error {
+	return r.model.AttachTags(r.db.WithContext(ctx), postID, tags)
+}
+
+func (r *gormTags) Detach(ctx context.Context, postID, tagID uint) error {
+	return r.model.DetachTag(r.db.WithContext(ctx), postID, tagID)
+}
+
+func (r *gormTags) Counts(ctx context.Context) ([]model.TagCount, error) {
+	counts, err := r.model.GetTagCounts(r.db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	return *counts, nil
+}
+
+func (r *gormTags) Merge(ctx context.Context, from, into *model.Tag) error {
+	return r.model.MergeTags(r.db.WithContext(ctx), from, into)
+}
+
+type gormCategories struct {
+	db    *gorm.DB
+	model model.Category
+}
+
+func (r *gormCategories) Create(ctx context.Context, category *model.Category) error {
+	return r.model.CreateCategory(r.db.WithContext(ctx), category)
+}
+
+func (r *gormCategories) GetBySlug(ctx context.Context, slug string) (*model.Category, error) {
+	return r.model.GetCategoryBySlug(r.db.WithContext(ctx), slug)
+}
+
+func (r *gormCategories) Attach(ctx context.Context, postID uint, categories []model.Category) error {
+	return r.model.AttachCategories(r.db.WithContext(ctx), postID, categories)
+}
+
+func (r *gormCategories) Detach(ctx context.Context, postID, categoryID uint) error {
+	return r.model.DetachCategory(r.db.WithContext(ctx), postID, categoryID)
+}
+
+func (r *gormCategories) Counts(ctx context.Context) ([]model.CategoryCount, error) {
+	counts, err := r.model.GetCategoryCounts(r.db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	return *counts, nil
+}
+
+type gormSessions struct {
+	db    *gorm.DB
+	model model.Session
+}
+
+func (r *gormSessions) Create(ctx context.Context, session *model.Session) error {
+	return r.model.CreateSession(r.db.WithContext(ctx), session)
+}
+
+func (r *gormSessions) GetByID(ctx context.Context, sessionID uint) (*model.Session, error) {
+	return r.model.GetSessionByID(r.db.WithContext(ctx), sessionID)
+}
+
+func (r *gormSessions) GetByTokenHash(ctx context.Context, tokenHash string) (*model.Session, error) {
+	return r.model.GetSessionByTokenHash(r.db.WithContext(ctx), tokenHash)
+}
+
+func (r *gormSessions) GetByPreviousTokenHash(ctx context.Context, tokenHash string) (*model.Session, error) {
+	return r.model.GetSessionByPreviousTokenHash(r.db.WithContext(ctx), tokenHash)
+}
+
+func (r *gormSessions) Rotate(ctx context.Context, session *model.Session, tokenHash string, expiresAt time.Time) error {
+	return r.model.RotateRefreshToken(r.db.WithContext(ctx), session, tokenHash, expiresAt)
+}
+
+func (r *gormSessions) Revoke(ctx context.Context, sessionID uint) error {
+	return r.model.RevokeSessionByID(r.db.WithContext(ctx), sessionID)
+}
+
+func (r *gormSessions) RevokeByUserID(ctx context.Context, userID uint) error {
+	return r.model.RevokeSessionsByUserID(r.db.WithContext(ctx), userID)
+}
+
+type gormTrash struct {
+	db    *gorm.DB
+	posts model.Post
+	users model.User
+}
+
+func (r *gormTrash) Posts(ctx context.Context, query model.PageQuery) ([]model.Post, *model.Page, error) {
+	posts, page, err := r.posts.GetTrashedPosts(r.db.WithContext(ctx), query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return *posts, page, nil
+}
+
+func (r *gormTrash) Users(ctx context.Context, query model.PageQuery) ([]model.UserResponse, *model.Page, error) {
+	users, page, err := r.users.GetTrashedUsers(r.db.WithContext(ctx), query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return *users, page, nil
+}
+
+func (r *gormTrash) GetPost(ctx context.Context, postID uint) (*model.Post, error) {
+	return r.posts.GetTrashedPostByID(r.db.WithContext(ctx), int(postID))
+}
+
+func (r *gormTrash) GetUser(ctx context.Context, userID uint) (*model.User, error) {
+	return r.users.GetTrashedUserByID(r.db.WithContext(ctx), int(userID))
+}
+
+func (r *gormTrash) RestorePost(ctx context.Context, postID uint) error {
+	return r.posts.RestorePostByID(r.db.WithContext(ctx), int(postID))
+}
+
+func (r *gormTrash) RestoreUser(ctx context.Context, user *model.User) error {
+	return r.users.RestoreUserByID(r.db.WithContext(ctx), user)
+}
+
+func (r *gormTrash) PurgePost(ctx context.Context, postID uint) error {
+	return r.posts.PurgePostByID(r.db.WithContext(ctx), int(postID))
+}
+
+func (r *gormTrash) PurgeUser(ctx context.Context, userID uint) error {
+	return r.users.PurgeUserByID(r.db.WithContext(ctx), int(userID))
+}
+
+func (r *gormTrash) PurgeExpiredPosts(ctx context.Context, before time.Time) (int64, error) {
+	return r.posts.PurgeExpiredPosts(r.db.WithContext(ctx), before)
+}
+
+func (r *gormTrash) PurgeExpiredUsers(ctx context.Context, before time.Time) (int64, error) {
+	return r.users.PurgeExpiredUsers(r.db.WithContext(ctx), before)
+}
@@ -0,0 +1,67 @@
+package repository_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/pooulad/blogo/internal/config"
+	"github.com/pooulad/blogo/internal/database"
+	"github.com/pooulad/blogo/internal/database/repository"
+	"github.com/pooulad/blogo/internal/database/repository/repositorytest"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// TestGormSqliteConformance runs the suite on a fresh in-memory sqlite
+// database per test.
+func TestGormSqliteConformance(t *testing.T) {
+	cfg := &config.Config{DB: config.DB{Driver: config.DriverSqlite, Sqlite: config.Sqlite{Path: ":memory:"}}}
+
+	repositorytest.Run(t, func(t *testing.T) repository.Repositories {
+		db, err := database.Open(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() {
+			if sqlDB, err := db.DB(); err == nil {
+				sqlDB.Close()
+			}
+		})
+
+		db = db.Session(&gorm.Session{Logger: logger.Discard})
+		if _, err := database.MigrateUp(context.Background(), db); err != nil {
+			t.Fatal(err)
+		}
+
+		return repository.NewGorm(db)
+	})
+}
+
+// TestGormConformance needs a Postgres database which it may wipe, it is
+// skipped unless BLOGO_TEST_POSTGRES_DSN points at one.
+func TestGormConformance(t *testing.T) {
+	dsn := os.Getenv("BLOGO_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("BLOGO_TEST_POSTGRES_DSN is not set")
+	}
+
+	db, err := database.OpenDialector(postgres.Open(dsn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	db = db.Session(&gorm.Session{Logger: logger.Discard})
+	if _, err := database.MigrateUp(context.Background(), db); err != nil {
+		t.Fatal(err)
+	}
+
+	repositorytest.Run(t, func(t *testing.T) repository.Repositories {
+		err := db.Exec("TRUNCATE users, posts, likes, user_follows, post_revisions, post_slugs, comments, sessions, tags, tag_aliases, post_tags, categories, post_categories RESTART IDENTITY CASCADE").Error
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return repository.NewGorm(db)
+	})
+}
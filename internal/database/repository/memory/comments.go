@@ -0,0 +1,100 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pooulad/blogo/internal/database/model"
+	"github.com/pooulad/blogo/internal/database/repository"
+	"gorm.io/gorm"
+)
+
+type comments store
+
+func (r *comments) Create(ctx context.Context, comment *model.Comment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	r.lastCommentID++
+	comment.ID = r.lastCommentID
+	comment.CreatedAt, comment.UpdatedAt = now, now
+	r.comments[comment.ID] = *comment
+
+	return nil
+}
+
+func (r *comments) GetByID(ctx context.Context, commentID uint) (*model.Comment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	comment, ok := r.comments[commentID]
+	if !ok || comment.DeletedAt.Valid {
+		return nil, fmt.Errorf("comment %w", repository.ErrNotFound)
+	}
+
+	return &comment, nil
+}
+
+func (r *comments) ListByPostID(ctx context.Context, postID uint) ([]model.Comment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	rows := []model.Comment{}
+	for _, comment := range r.comments {
+		if comment.PostRefer == postID {
+			rows = append(rows, comment)
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if !rows[i].CreatedAt.Equal(rows[j].CreatedAt) {
+			return rows[i].CreatedAt.Before(rows[j].CreatedAt)
+		}
+		return rows[i].ID < rows[j].ID
+	})
+
+	return rows, nil
+}
+
+func (r *comments) Update(ctx context.Context, comment *model.Comment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	comment.UpdatedAt = time.Now()
+	r.comments[comment.ID] = *comment
+
+	return nil
+}
+
+func (r *comments) Delete(ctx context.Context, commentID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if comment, ok := r.comments[commentID]; ok && !comment.DeletedAt.Valid {
+		comment.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+		r.comments[commentID] = comment
+	}
+
+	return nil
+}
+
+func (r *comments) CountByPostIDs(ctx context.Context, postIDs []uint) (map[uint]int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	wanted := make(map[uint]bool, len(postIDs))
+	for _, postID := range postIDs {
+		wanted[postID] = true
+	}
+
+	counts := map[uint]int64{}
+	for _, comment := range r.comments {
+		if wanted[comment.PostRefer] && !comment.DeletedAt.Valid {
+			counts[comment.PostRefer]++
+		}
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,925 @@
+// Package memory keeps the data of the app in memory. it behaves like the
+// GORM repositories, so the app can be tested without a database.
+package memory
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pooulad/blogo/internal/database/model"
+	"github.com/pooulad/blogo/internal/database/repository"
+	"github.com/pooulad/blogo/internal/render"
+	"github.com/pooulad/blogo/utilities"
+	"gorm.io/gorm"
+)
+
+type pair struct {
+	from, to uint
+}
+
+// store holds the rows of every repository. the repositories are views of
+// the same store, so a user deleted by one is gone for the others.
+type store struct {
+	mu             sync.RWMutex
+	users          map[uint]model.User
+	posts          map[uint]model.Post
+	likes          map[pair]bool // user id to post id
+	follows        map[pair]bool // follower id to followed id
+	revisions      map[uint][]model.PostRevision
+	oldSlugs       map[string]uint // old slug to post id
+	comments       map[uint]model.Comment
+	tags           map[uint]model.Tag
+	tagAliases     map[string]uint // slug to tag id
+	postTags       map[pair]bool   // post id to tag id
+	categories     map[uint]model.Category
+	postCategories map[pair]bool // post id to category id
+	sessions       map[uint]model.Session
+	lastUserID     uint
+	lastPostID     uint
+	lastRevisionID uint
+	lastCommentID  uint
+	lastTagID      uint
+	lastCategoryID uint
+	lastSessionID  uint
+}
+
+// New returns empty in-memory repositories.
+func New() repository.Repositories {
+	s := &store{
+		users:          map[uint]model.User{},
+		posts:          map[uint]model.Post{},
+		likes:          map[pair]bool{},
+		follows:        map[pair]bool{},
+		revisions:      map[uint][]model.PostRevision{},
+		oldSlugs:       map[string]uint{},
+		comments:       map[uint]model.Comment{},
+		tags:           map[uint]model.Tag{},
+		tagAliases:     map[string]uint{},
+		postTags:       map[pair]bool{},
+		categories:     map[uint]model.Category{},
+		postCategories: map[pair]bool{},
+		sessions:       map[uint]model.Session{},
+	}
+
+	return repository.Repositories{
+		Users:      (*users)(s),
+		Posts:      (*posts)(s),
+		Follows:    (*follows)(s),
+		Comments:   (*comments)(s),
+		Tags:       (*tags)(s),
+		Categories: (*categories)(s),
+		Sessions:   (*sessions)(s),
+		Trash:      (*trash)(s),
+	}
+}
+
+func (s *store) user(userID uint) (model.User, bool) {
+	user, ok := s.users[userID]
+	return user, ok && !user.DeletedAt.Valid
+}
+
+func (s *store) post(postID uint) (model.Post, bool) {
+	post, ok := s.posts[postID]
+	return post, ok && !post.DeletedAt.Valid
+}
+
+// visiblePosts returns the posts of the author which viewerID can see.
+func (s *store) visiblePosts(authorID, viewerID uint) []model.Post {
+	posts := []model.Post{}
+	for _, post := range s.posts {
+		if post.UserRefer == authorID && !post.DeletedAt.Valid && post.IsVisibleTo(viewerID) {
+			posts = append(posts, post)
+		}
+	}
+	sort.Slice(posts, func(i, j int) bool {
+		return posts[i].ID < posts[j].ID
+	})
+
+	return posts
+}
+
+// withJoins fills in the tags and categories of the post.
+func (s *store) withJoins(post model.Post) model.Post {
+	post.Tags, post.Categories = []model.Tag{}, []model.Category{}
+	for join := range s.postTags {
+		if tag, ok := s.tags[join.to]; ok && join.from == post.ID {
+			post.Tags = append(post.Tags, tag)
+		}
+	}
+	for join := range s.postCategories {
+		if category, ok := s.categories[join.to]; ok && join.from == post.ID {
+			post.Categories = append(post.Categories, category)
+		}
+	}
+	sort.Slice(post.Tags, func(i, j int) bool {
+		return post.Tags[i].ID < post.Tags[j].ID
+	})
+	sort.Slice(post.Categories, func(i, j int) bool {
+		return post.Categories[i].ID < post.Categories[j].ID
+	})
+
+	return post
+}
+
+// addRevision snapshots the post as its next revision.
+func (s *store) addRevision(post model.Post, editorID uint) {
+	s.lastRevisionID++
+	s.revisions[post.ID] = append(s.revisions[post.ID], model.PostRevision{
+		ID:          s.lastRevisionID,
+		PostRefer:   post.ID,
+		Number:      len(s.revisions[post.ID]) + 1,
+		Title:       post.Title,
+		Content:     post.Content,
+		Format:      post.Format,
+		EditorRefer: editorID,
+		CreatedAt:   time.Now(),
+	})
+}
+
+// likedBy returns the users who like the post.
+func (s *store) likedBy(postID uint) []model.User {
+	users := []model.User{}
+	for like := range s.likes {
+		if like.to != postID {
+			continue
+		}
+		if user, ok := s.user(like.from); ok {
+			users = append(users, user)
+		}
+	}
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].ID < users[j].ID
+	})
+
+	return users
+}
+
+func (s *store) userResponse(user model.User, viewerID uint) model.UserResponse {
+	var response model.UserResponse
+	response.ID = user.ID
+	response.CreatedAt = user.CreatedAt
+	response.FirstName = user.FirstName
+	response.LastName = user.LastName
+	response.Username = user.Username
+	response.Email = user.Email
+	response.Role = user.Role
+	response.Skill = user.Skill
+	response.Posts = s.visiblePosts(user.ID, viewerID)
+
+	return response
+}
+
+func userKey(user model.UserResponse) (time.Time, uint) {
+	return user.CreatedAt, user.ID
+}
+
+type users store
+
+func (r *users) Create(ctx context.Context, user *model.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	r.lastUserID++
+	user.ID = r.lastUserID
+	user.CreatedAt, user.UpdatedAt = now, now
+
+	row := *user
+	row.Posts, row.LikedPosts, row.Followers, row.Following = nil, nil, nil, nil
+	r.users[row.ID] = row
+
+	return nil
+}
+
+func (r *users) GetByID(ctx context.Context, userID, viewerID uint) (*model.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := (*store)(r).user(userID)
+	if !ok {
+		return nil, fmt.Errorf("user %w", repository.ErrNotFound)
+	}
+	user.Posts = (*store)(r).visiblePosts(userID, viewerID)
+
+	return &user, nil
+}
+
+func (r *users) GetByUsername(ctx context.Context, username string) (*model.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, user := range r.users {
+		if user.Username == username && !user.DeletedAt.Valid {
+			return &user, nil
+		}
+	}
+
+	return nil, fmt.Errorf("user %w", repository.ErrNotFound)
+}
+
+func (r *users) IsEmailTaken(ctx context.Context, email string, exceptUserID uint) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, user := range r.users {
+		if strings.EqualFold(user.Email, email) && user.ID != exceptUserID && !user.DeletedAt.Valid {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (r *users) Update(ctx context.Context, user *model.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user.UpdatedAt = time.Now()
+
+	row := *user
+	row.Posts, row.LikedPosts, row.Followers, row.Following = nil, nil, nil, nil
+	r.users[row.ID] = row
+
+	return nil
+}
+
+func (r *users) Delete(ctx context.Context, userID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := (*store)(r).user(userID)
+	if !ok {
+		return fmt.Errorf("user %w", repository.ErrNotFound)
+	}
+
+	deletedAt := gorm.DeletedAt{Time: time.Now(), Valid: true}
+	user.DeletedAt = deletedAt
+	r.users[userID] = user
+
+	for id, post := range r.posts {
+		if post.UserRefer == userID && !post.DeletedAt.Valid {
+			post.DeletedAt = deletedAt
+			r.posts[id] = post
+		}
+	}
+
+	return nil
+}
+
+func (r *users) List(ctx context.Context, viewerID uint, query model.UserQuery) ([]model.UserResponse, *model.Page, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var rows []model.UserResponse
+	for _, user := range r.users {
+		if user.DeletedAt.Valid {
+			continue
+		}
+		if query.Role != "" && user.Role != query.Role {
+			continue
+		}
+		if query.Active != nil && user.Active != *query.Active {
+			continue
+		}
+		rows = append(rows, (*store)(r).userResponse(user, viewerID))
+	}
+
+	rows, page, err := model.PageRows(rows, query.PageQuery, userKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return rows, &page, nil
+}
+
+func (r *users) Usernames(ctx context.Context, userIDs []uint) (map[uint]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	usernames := make(map[uint]string, len(userIDs))
+	for _, userID := range userIDs {
+		if user, ok := (*store)(r).user(userID); ok {
+			usernames[userID] = user.Username
+		}
+	}
+
+	return usernames, nil
+}
+
+// Search finds the users with every word of the query in their names or
+// skill, like the search of the GORM repositories on sqlite.
+func (r *users) Search(ctx context.Context, query model.SearchQuery) ([]model.UserSearchResult, *model.Page, error) {
+	offset, err := query.Offset()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var results []model.UserSearchResult
+	for _, user := range r.users {
+		if user.DeletedAt.Valid {
+			continue
+		}
+
+		rank, ok := searchRank(query.Q, []string{user.Username, user.FirstName, user.LastName, user.Skill}, []string{user.Username, user.FirstName, user.LastName})
+		if !ok {
+			continue
+		}
+
+		results = append(results, model.UserSearchResult{
+			ID:        user.ID,
+			Username:  user.Username,
+			FirstName: user.FirstName,
+			LastName:  user.LastName,
+			Skill:     user.Skill,
+			Snippet:   html.EscapeString(user.Skill),
+			Rank:      rank,
+		})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Rank != results[j].Rank {
+			return results[i].Rank > results[j].Rank
+		}
+		return results[i].ID > results[j].ID
+	})
+
+	results, page := model.NextOffsetPage(pageAt(results, offset, query.Limit), query.PageQuery, offset)
+	return results, &page, nil
+}
+
+// searchRank reports whether every word of q is in one of the texts, and
+// counts the words found in rankTexts.
+func searchRank(q string, texts, rankTexts []string) (float64, bool) {
+	var rank float64
+	for _, word := range model.SearchWords(q) {
+		found := false
+		for _, text := range texts {
+			if strings.Contains(strings.ToLower(text), word) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0, false
+		}
+
+		for _, text := range rankTexts {
+			if strings.Contains(strings.ToLower(text), word) {
+				rank++
+			}
+		}
+	}
+
+	return rank, true
+}
+
+// pageAt returns the rows from offset on, one more than the limit like the
+// offset queries of the GORM repositories.
+func pageAt[T any](rows []T, offset, limit int) []T {
+	if offset >= len(rows) {
+		return nil
+	}
+	rows = rows[offset:]
+	if len(rows) > limit+1 {
+		rows = rows[:limit+1]
+	}
+
+	return rows
+}
+
+type posts store
+
+func (r *posts) Create(ctx context.Context, post *model.Post) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := (*store)(r).checkSlugUnique(*post); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	r.lastPostID++
+	post.ID = r.lastPostID
+	post.CreatedAt, post.UpdatedAt = now, now
+	// the column defaults of the posts table
+	if post.Format == "" {
+		post.Format = render.FormatMarkdown
+	}
+	if post.Status == "" {
+		post.Status = model.PostStatusPublished
+	}
+
+	row := *post
+	row.LikedBy, row.Tags, row.Categories = nil, nil, nil
+	r.posts[row.ID] = row
+	(*store)(r).addRevision(row, row.UserRefer)
+
+	return nil
+}
+
+func (r *posts) GetByID(ctx context.Context, postID uint) (*model.Post, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	post, ok := (*store)(r).post(postID)
+	if !ok {
+		return nil, fmt.Errorf("post %w", repository.ErrNotFound)
+	}
+	post = (*store)(r).withJoins(post)
+	post.LikedBy = (*store)(r).likedBy(postID)
+
+	return &post, nil
+}
+
+func (r *posts) GetBySlug(ctx context.Context, slug string) (*model.Post, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, post := range r.posts {
+		if post.Slug == slug && !post.DeletedAt.Valid {
+			return &post, nil
+		}
+	}
+
+	return nil, fmt.Errorf("post %w", repository.ErrNotFound)
+}
+
+func (r *posts) GetByOldSlug(ctx context.Context, slug string) (*model.Post, error) {
+	r.mu.RLock()
+	postID, ok := r.oldSlugs[slug]
+	r.mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("post %w", repository.ErrNotFound)
+	}
+
+	return r.GetByID(ctx, postID)
+}
+
+func (r *posts) IsSlugTaken(ctx context.Context, slug string, exceptPostID uint) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return (*store)(r).isSlugTaken(slug, exceptPostID), nil
+}
+
+// isSlugTaken looks at the trashed posts too, their slugs come back with
+// them.
+func (s *store) isSlugTaken(slug string, exceptPostID uint) bool {
+	for _, post := range s.posts {
+		if post.Slug == slug && post.ID != exceptPostID {
+			return true
+		}
+	}
+
+	postID, ok := s.oldSlugs[slug]
+	return ok && postID != exceptPostID
+}
+
+// checkSlugUnique is the unique index on the slugs of posts.
+func (s *store) checkSlugUnique(post model.Post) error {
+	if post.Slug == "" {
+		return nil
+	}
+
+	for _, stored := range s.posts {
+		if stored.Slug == post.Slug && stored.ID != post.ID {
+			return fmt.Errorf("slug %s: %w", post.Slug, gorm.ErrDuplicatedKey)
+		}
+	}
+
+	return nil
+}
+
+func (r *posts) UniqueSlug(ctx context.Context, text string, exceptPostID uint) (string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	base := utilities.Slugify(text)
+	if base == "" {
+		base = "post"
+	}
+
+	slug := base
+	for i := 2; (*store)(r).isSlugTaken(slug, exceptPostID); i++ {
+		slug = fmt.Sprintf("%s-%d", base, i)
+	}
+
+	return slug, nil
+}
+
+func (r *posts) Update(ctx context.Context, post *model.Post, editorID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := (*store)(r).checkSlugUnique(*post); err != nil {
+		return err
+	}
+
+	if stored := r.posts[post.ID]; stored.Slug != post.Slug {
+		if _, ok := r.oldSlugs[stored.Slug]; stored.Slug != "" && !ok {
+			r.oldSlugs[stored.Slug] = post.ID
+		}
+		// the post may take back one of its own old slugs
+		if r.oldSlugs[post.Slug] == post.ID {
+			delete(r.oldSlugs, post.Slug)
+		}
+	}
+
+	post.UpdatedAt = time.Now()
+
+	row := *post
+	row.LikedBy, row.Tags, row.Categories = nil, nil, nil
+	r.posts[row.ID] = row
+	(*store)(r).addRevision(row, editorID)
+
+	return nil
+}
+
+func (r *posts) Transfer(ctx context.Context, postID, userID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if post, ok := (*store)(r).post(postID); ok {
+		post.UserRefer = userID
+		r.posts[postID] = post
+	}
+
+	return nil
+}
+
+func (r *posts) Delete(ctx context.Context, postID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	post, ok := (*store)(r).post(postID)
+	if !ok {
+		return fmt.Errorf("post %w", repository.ErrNotFound)
+	}
+
+	post.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	r.posts[postID] = post
+
+	return nil
+}
+
+func (r *posts) List(ctx context.Context, viewerID uint, query model.PostQuery) ([]model.Post, *model.Page, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var rows []model.Post
+	for _, post := range r.posts {
+		if post.DeletedAt.Valid || !post.IsVisibleTo(viewerID) {
+			continue
+		}
+		if query.AuthorID != 0 && post.UserRefer != query.AuthorID {
+			continue
+		}
+		if query.From != nil && post.CreatedAt.Before(*query.From) {
+			continue
+		}
+		if query.To != nil && !post.CreatedAt.Before(*query.To) {
+			continue
+		}
+		if query.LikedByMe && !r.likes[pair{viewerID, post.ID}] {
+			continue
+		}
+
+		post = (*store)(r).withJoins(post)
+		if query.Tag != "" && !hasTag(post, query.Tag) {
+			continue
+		}
+		if query.Category != "" && !hasCategory(post, query.Category) {
+			continue
+		}
+
+		post.LikedBy = (*store)(r).likedBy(post.ID)
+		rows = append(rows, post)
+	}
+
+	rows, page, err := model.PageRows(rows, query.PageQuery, func(post model.Post) (time.Time, uint) {
+		return post.CreatedAt, post.ID
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return rows, &page, nil
+}
+
+func hasTag(post model.Post, slug string) bool {
+	for _, tag := range post.Tags {
+		if tag.Slug == slug {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasCategory(post model.Post, slug string) bool {
+	for _, category := range post.Categories {
+		if category.Slug == slug {
+			return true
+		}
+	}
+
+	return false
+}
+
+// publishedKey orders the feeds by (published_at, id).
+func publishedKey(post model.Post) (time.Time, uint) {
+	if post.PublishedAt == nil {
+		return post.CreatedAt, post.ID
+	}
+	return *post.PublishedAt, post.ID
+}
+
+func (r *posts) HomeFeed(ctx context.Context, userID uint, query model.PageQuery) ([]model.Post, *model.Page, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var rows []model.Post
+	for _, post := range r.posts {
+		if post.DeletedAt.Valid || post.Status != model.PostStatusPublished || !r.follows[pair{userID, post.UserRefer}] {
+			continue
+		}
+		rows = append(rows, (*store)(r).withJoins(post))
+	}
+
+	rows, page, err := model.PageRows(rows, query, publishedKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return rows, &page, nil
+}
+
+func (r *posts) Feed(ctx context.Context, query model.FeedQuery, limit int) ([]model.Post, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	rows := []model.Post{}
+	for _, post := range r.posts {
+		if post.DeletedAt.Valid || post.Status != model.PostStatusPublished {
+			continue
+		}
+		if query.AuthorID != 0 && post.UserRefer != query.AuthorID {
+			continue
+		}
+
+		post = (*store)(r).withJoins(post)
+		if query.Tag != "" && !hasTag(post, query.Tag) {
+			continue
+		}
+		post.Categories = nil
+		rows = append(rows, post)
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		iPublished, iID := publishedKey(rows[i])
+		jPublished, jID := publishedKey(rows[j])
+		if !iPublished.Equal(jPublished) {
+			return iPublished.After(jPublished)
+		}
+		return iID > jID
+	})
+	if len(rows) > limit {
+		rows = rows[:limit]
+	}
+
+	return rows, nil
+}
+
+// Search finds the posts with every word of the query in their title or
+// content, like the search of the GORM repositories on sqlite.
+func (r *posts) Search(ctx context.Context, viewerID uint, query model.SearchQuery) ([]model.PostSearchResult, *model.Page, error) {
+	offset, err := query.Offset()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var results []model.PostSearchResult
+	for _, post := range r.posts {
+		if post.DeletedAt.Valid || !post.IsVisibleTo(viewerID) {
+			continue
+		}
+
+		rank, ok := searchRank(query.Q, []string{post.Title, post.Content}, []string{post.Title})
+		if !ok {
+			continue
+		}
+
+		text := post.ContentHTML
+		if text == "" {
+			text = post.Content
+		}
+		results = append(results, model.PostSearchResult{
+			ID:          post.ID,
+			Title:       post.Title,
+			Headline:    html.EscapeString(post.Title),
+			Snippet:     model.PlainSnippet(text),
+			UserRefer:   post.UserRefer,
+			PublishedAt: post.PublishedAt,
+			Rank:        rank,
+		})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Rank != results[j].Rank {
+			return results[i].Rank > results[j].Rank
+		}
+		return results[i].ID > results[j].ID
+	})
+
+	results, page := model.NextOffsetPage(pageAt(results, offset, query.Limit), query.PageQuery, offset)
+	return results, &page, nil
+}
+
+func (r *posts) Like(ctx context.Context, userID, postID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// the foreign and primary keys of the likes table
+	if _, ok := r.users[userID]; !ok {
+		return fmt.Errorf("user %w", repository.ErrNotFound)
+	}
+	if _, ok := r.posts[postID]; !ok {
+		return fmt.Errorf("post %w", repository.ErrNotFound)
+	}
+	if r.likes[pair{userID, postID}] {
+		return fmt.Errorf("post %d is already liked by user %d", postID, userID)
+	}
+
+	r.likes[pair{userID, postID}] = true
+	return nil
+}
+
+func (r *posts) Unlike(ctx context.Context, userID, postID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.likes, pair{userID, postID})
+	return nil
+}
+
+func (r *posts) IsLiked(ctx context.Context, userID, postID uint) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.likes[pair{userID, postID}], nil
+}
+
+func (r *posts) LikeStats(ctx context.Context, viewerID uint, postIDs []uint) (map[uint]model.LikeStats, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stats := make(map[uint]model.LikeStats, len(postIDs))
+	for _, postID := range postIDs {
+		for like := range r.likes {
+			if like.to != postID {
+				continue
+			}
+			s := stats[postID]
+			s.PostID = postID
+			s.LikedCount++
+			s.Liked = s.Liked || like.from == viewerID
+			stats[postID] = s
+		}
+	}
+
+	return stats, nil
+}
+
+func (r *posts) PublishScheduled(ctx context.Context, now time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var count int64
+	for id, post := range r.posts {
+		if post.DeletedAt.Valid || post.Status != model.PostStatusScheduled {
+			continue
+		}
+		if post.PublishedAt == nil || post.PublishedAt.After(now) {
+			continue
+		}
+
+		post.Status = model.PostStatusPublished
+		r.posts[id] = post
+		count++
+	}
+
+	return count, nil
+}
+
+func (r *posts) Revisions(ctx context.Context, postID uint, query model.PageQuery) ([]model.PostRevision, *model.Page, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	rows, page, err := model.PageRows(r.revisions[postID], query, func(revision model.PostRevision) (time.Time, uint) {
+		return revision.CreatedAt, revision.ID
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return rows, &page, nil
+}
+
+func (r *posts) Revision(ctx context.Context, postID uint, number int) (*model.PostRevision, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, revision := range r.revisions[postID] {
+		if revision.Number == number {
+			return &revision, nil
+		}
+	}
+
+	return nil, fmt.Errorf("revision %w", repository.ErrNotFound)
+}
+
+type follows store
+
+func (r *follows) Follow(ctx context.Context, followerID, followedID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// the foreign and primary keys of the user_follows table
+	if _, ok := r.users[followerID]; !ok {
+		return fmt.Errorf("user %w", repository.ErrNotFound)
+	}
+	if _, ok := r.users[followedID]; !ok {
+		return fmt.Errorf("user %w", repository.ErrNotFound)
+	}
+	if r.follows[pair{followerID, followedID}] {
+		return fmt.Errorf("user %d already follows user %d", followerID, followedID)
+	}
+
+	r.follows[pair{followerID, followedID}] = true
+	return nil
+}
+
+func (r *follows) Unfollow(ctx context.Context, followerID, followedID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.follows, pair{followerID, followedID})
+	return nil
+}
+
+func (r *follows) IsFollowing(ctx context.Context, followerID, followedID uint) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.follows[pair{followerID, followedID}], nil
+}
+
+func (r *follows) Followers(ctx context.Context, userID, viewerID uint, query model.PageQuery) ([]model.UserResponse, *model.Page, error) {
+	return r.page(userID, viewerID, query, func(follow pair) (uint, bool) {
+		return follow.from, follow.to == userID
+	})
+}
+
+func (r *follows) Following(ctx context.Context, userID, viewerID uint, query model.PageQuery) ([]model.UserResponse, *model.Page, error) {
+	return r.page(userID, viewerID, query, func(follow pair) (uint, bool) {
+		return follow.to, follow.from == userID
+	})
+}
+
+// page pages through the users picked from the follows of userID.
+func (r *follows) page(userID, viewerID uint, query model.PageQuery, pick func(follow pair) (uint, bool)) ([]model.UserResponse, *model.Page, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, ok := (*store)(r).user(userID); !ok {
+		return nil, nil, fmt.Errorf("user %w", repository.ErrNotFound)
+	}
+
+	var rows []model.UserResponse
+	for follow := range r.follows {
+		id, ok := pick(follow)
+		if !ok {
+			continue
+		}
+		if user, ok := (*store)(r).user(id); ok {
+			rows = append(rows, (*store)(r).userResponse(user, viewerID))
+		}
+	}
+
+	rows, page, err := model.PageRows(rows, query, userKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return rows, &page, nil
+}
@@ -0,0 +1,15 @@
+package memory_test
+
+import (
+	"testing"
+
+	"github.com/pooulad/blogo/internal/database/repository"
+	"github.com/pooulad/blogo/internal/database/repository/memory"
+	"github.com/pooulad/blogo/internal/database/repository/repositorytest"
+)
+
+func TestConformance(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) repository.Repositories {
+		return memory.New()
+	})
+}
@@ -0,0 +1,114 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pooulad/blogo/internal/database/model"
+	"github.com/pooulad/blogo/internal/database/repository"
+)
+
+type sessions store
+
+func (r *sessions) Create(ctx context.Context, session *model.Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	r.lastSessionID++
+	session.ID = r.lastSessionID
+	session.CreatedAt, session.UpdatedAt = now, now
+
+	row := *session
+	row.User = model.User{}
+	r.sessions[row.ID] = row
+
+	return nil
+}
+
+func (r *sessions) GetByID(ctx context.Context, sessionID uint) (*model.Session, error) {
+	return r.find(func(session model.Session) bool {
+		return session.ID == sessionID
+	})
+}
+
+func (r *sessions) GetByTokenHash(ctx context.Context, tokenHash string) (*model.Session, error) {
+	session, err := r.find(func(session model.Session) bool {
+		return session.RefreshTokenHash == tokenHash
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	session.User, _ = (*store)(r).user(session.UserRefer)
+	return session, nil
+}
+
+func (r *sessions) GetByPreviousTokenHash(ctx context.Context, tokenHash string) (*model.Session, error) {
+	return r.find(func(session model.Session) bool {
+		return session.PreviousTokenHash == tokenHash
+	})
+}
+
+func (r *sessions) find(match func(session model.Session) bool) (*model.Session, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, session := range r.sessions {
+		if match(session) {
+			return &session, nil
+		}
+	}
+
+	return nil, fmt.Errorf("session %w", repository.ErrNotFound)
+}
+
+func (r *sessions) Rotate(ctx context.Context, session *model.Session, tokenHash string, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// another request rotated the token first
+	row, ok := r.sessions[session.ID]
+	if !ok || row.RefreshTokenHash != session.RefreshTokenHash {
+		return model.ErrTokenReused
+	}
+
+	row.PreviousTokenHash = session.RefreshTokenHash
+	row.RefreshTokenHash = tokenHash
+	row.ExpiresAt = expiresAt
+	row.UpdatedAt = time.Now()
+	r.sessions[row.ID] = row
+
+	return nil
+}
+
+func (r *sessions) Revoke(ctx context.Context, sessionID uint) error {
+	return r.revoke(func(session model.Session) bool {
+		return session.ID == sessionID
+	})
+}
+
+func (r *sessions) RevokeByUserID(ctx context.Context, userID uint) error {
+	return r.revoke(func(session model.Session) bool {
+		return session.UserRefer == userID
+	})
+}
+
+func (r *sessions) revoke(match func(session model.Session) bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for id, session := range r.sessions {
+		if session.RevokedAt == nil && match(session) {
+			session.RevokedAt = &now
+			r.sessions[id] = session
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,234 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pooulad/blogo/internal/database/model"
+	"github.com/pooulad/blogo/internal/database/repository"
+	"github.com/pooulad/blogo/utilities"
+)
+
+type tags store
+
+func (r *tags) ResolveSlug(ctx context.Context, name string) (string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return (*store)(r).resolveTagSlug(name), nil
+}
+
+func (s *store) resolveTagSlug(name string) string {
+	slug := utilities.Slugify(name)
+
+	tagID, ok := s.tagAliases[slug]
+	if !ok {
+		return slug
+	}
+
+	tag, ok := s.tags[tagID]
+	if !ok {
+		return slug
+	}
+
+	return tag.Slug
+}
+
+func (s *store) tagBySlug(slug string) (model.Tag, bool) {
+	for _, tag := range s.tags {
+		if tag.Slug == slug {
+			return tag, true
+		}
+	}
+
+	return model.Tag{}, false
+}
+
+func (r *tags) GetBySlug(ctx context.Context, slug string) (*model.Tag, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tag, ok := (*store)(r).tagBySlug(slug)
+	if !ok {
+		return nil, fmt.Errorf("tag %w", repository.ErrNotFound)
+	}
+
+	return &tag, nil
+}
+
+func (r *tags) FindOrCreate(ctx context.Context, names []string) ([]model.Tag, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var found []model.Tag
+	seen := map[string]bool{}
+
+	for _, name := range names {
+		slug := (*store)(r).resolveTagSlug(name)
+		if slug == "" || seen[slug] {
+			continue
+		}
+		seen[slug] = true
+
+		tag, ok := (*store)(r).tagBySlug(slug)
+		if !ok {
+			r.lastTagID++
+			tag = model.Tag{ID: r.lastTagID, Name: name, Slug: slug, CreatedAt: time.Now()}
+			r.tags[tag.ID] = tag
+		}
+
+		found = append(found, tag)
+	}
+
+	return found, nil
+}
+
+func (r *tags) Attach(ctx context.Context, postID uint, tags []model.Tag) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, tag := range tags {
+		r.postTags[pair{postID, tag.ID}] = true
+	}
+
+	return nil
+}
+
+func (r *tags) Detach(ctx context.Context, postID, tagID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.postTags, pair{postID, tagID})
+	return nil
+}
+
+func (r *tags) Counts(ctx context.Context) ([]model.TagCount, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	counts := map[uint]int64{}
+	for join := range r.postTags {
+		if post, ok := (*store)(r).post(join.from); ok && post.Status == model.PostStatusPublished {
+			counts[join.to]++
+		}
+	}
+
+	// tags without published posts are left out, like the inner joins of the
+	// GORM repository
+	var rows []model.TagCount
+	for tagID, count := range counts {
+		if tag, ok := r.tags[tagID]; ok {
+			rows = append(rows, model.TagCount{Name: tag.Name, Slug: tag.Slug, Count: count})
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Count != rows[j].Count {
+			return rows[i].Count > rows[j].Count
+		}
+		return rows[i].Slug < rows[j].Slug
+	})
+
+	return rows, nil
+}
+
+func (r *tags) Merge(ctx context.Context, from, into *model.Tag) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for join := range r.postTags {
+		if join.to == from.ID {
+			delete(r.postTags, join)
+			r.postTags[pair{join.from, into.ID}] = true
+		}
+	}
+
+	// aliases of the merged tag follow it into the new tag
+	for slug, tagID := range r.tagAliases {
+		if tagID == from.ID {
+			r.tagAliases[slug] = into.ID
+		}
+	}
+	r.tagAliases[from.Slug] = into.ID
+
+	delete(r.tags, from.ID)
+	return nil
+}
+
+type categories store
+
+func (r *categories) Create(ctx context.Context, category *model.Category) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// the unique index on the slug of categories
+	for _, stored := range r.categories {
+		if stored.Slug == category.Slug {
+			return fmt.Errorf("category %s already exists", category.Slug)
+		}
+	}
+
+	r.lastCategoryID++
+	category.ID = r.lastCategoryID
+	category.CreatedAt = time.Now()
+	r.categories[category.ID] = *category
+
+	return nil
+}
+
+func (r *categories) GetBySlug(ctx context.Context, slug string) (*model.Category, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, category := range r.categories {
+		if category.Slug == slug {
+			return &category, nil
+		}
+	}
+
+	return nil, fmt.Errorf("category %w", repository.ErrNotFound)
+}
+
+func (r *categories) Attach(ctx context.Context, postID uint, categories []model.Category) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, category := range categories {
+		r.postCategories[pair{postID, category.ID}] = true
+	}
+
+	return nil
+}
+
+func (r *categories) Detach(ctx context.Context, postID, categoryID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.postCategories, pair{postID, categoryID})
+	return nil
+}
+
+func (r *categories) Counts(ctx context.Context) ([]model.CategoryCount, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	rows := []model.CategoryCount{}
+	for _, category := range r.categories {
+		row := model.CategoryCount{Name: category.Name, Slug: category.Slug}
+		for join := range r.postCategories {
+			if join.to != category.ID {
+				continue
+			}
+			if post, ok := (*store)(r).post(join.from); ok && post.Status == model.PostStatusPublished {
+				row.Count++
+			}
+		}
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i].Name < rows[j].Name
+	})
+
+	return rows, nil
+}
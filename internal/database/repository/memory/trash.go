@@ -0,0 +1,232 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pooulad/blogo/internal/database/model"
+	"github.com/pooulad/blogo/internal/database/repository"
+	"gorm.io/gorm"
+)
+
+type trash store
+
+func (r *trash) Posts(ctx context.Context, query model.PageQuery) ([]model.Post, *model.Page, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var rows []model.Post
+	for _, post := range r.posts {
+		if post.DeletedAt.Valid {
+			rows = append(rows, post)
+		}
+	}
+
+	rows, page, err := model.PageRows(rows, query, func(post model.Post) (time.Time, uint) {
+		return post.CreatedAt, post.ID
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return rows, &page, nil
+}
+
+func (r *trash) Users(ctx context.Context, query model.PageQuery) ([]model.UserResponse, *model.Page, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var rows []model.UserResponse
+	for _, user := range r.users {
+		if !user.DeletedAt.Valid {
+			continue
+		}
+
+		// trashed users are listed without their posts
+		response := (*store)(r).userResponse(user, 0)
+		response.Posts = nil
+		response.UpdatedAt = user.UpdatedAt
+		response.DeletedAt = user.DeletedAt
+		rows = append(rows, response)
+	}
+
+	rows, page, err := model.PageRows(rows, query, userKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return rows, &page, nil
+}
+
+func (r *trash) GetPost(ctx context.Context, postID uint) (*model.Post, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	post, ok := r.posts[postID]
+	if !ok || !post.DeletedAt.Valid {
+		return nil, fmt.Errorf("post %w in trash", repository.ErrNotFound)
+	}
+
+	return &post, nil
+}
+
+func (r *trash) GetUser(ctx context.Context, userID uint) (*model.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[userID]
+	if !ok || !user.DeletedAt.Valid {
+		return nil, fmt.Errorf("user %w in trash", repository.ErrNotFound)
+	}
+
+	return &user, nil
+}
+
+func (r *trash) RestorePost(ctx context.Context, postID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if post, ok := r.posts[postID]; ok {
+		post.DeletedAt = gorm.DeletedAt{}
+		r.posts[postID] = post
+	}
+
+	return nil
+}
+
+func (r *trash) RestoreUser(ctx context.Context, user *model.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, post := range r.posts {
+		if post.UserRefer == user.ID && post.DeletedAt.Valid && post.DeletedAt.Time.Equal(user.DeletedAt.Time) {
+			post.DeletedAt = gorm.DeletedAt{}
+			r.posts[id] = post
+		}
+	}
+
+	if stored, ok := r.users[user.ID]; ok {
+		stored.DeletedAt = gorm.DeletedAt{}
+		r.users[user.ID] = stored
+	}
+
+	return nil
+}
+
+func (r *trash) PurgePost(ctx context.Context, postID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	(*store)(r).purgePost(postID)
+	return nil
+}
+
+func (r *trash) PurgeUser(ctx context.Context, userID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	(*store)(r).purgeUser(userID)
+	return nil
+}
+
+func (r *trash) PurgeExpiredPosts(ctx context.Context, before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var purged int64
+	for id, post := range r.posts {
+		if post.DeletedAt.Valid && post.DeletedAt.Time.Before(before) {
+			(*store)(r).purgePost(id)
+			purged++
+		}
+	}
+
+	return purged, nil
+}
+
+func (r *trash) PurgeExpiredUsers(ctx context.Context, before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var purged int64
+	for id, user := range r.users {
+		if user.DeletedAt.Valid && user.DeletedAt.Time.Before(before) {
+			(*store)(r).purgeUser(id)
+			purged++
+		}
+	}
+
+	return purged, nil
+}
+
+// purgePost deletes the post with every row which refers to it.
+func (s *store) purgePost(postID uint) {
+	for like := range s.likes {
+		if like.to == postID {
+			delete(s.likes, like)
+		}
+	}
+	for join := range s.postTags {
+		if join.from == postID {
+			delete(s.postTags, join)
+		}
+	}
+	for join := range s.postCategories {
+		if join.from == postID {
+			delete(s.postCategories, join)
+		}
+	}
+	for id, comment := range s.comments {
+		if comment.PostRefer == postID {
+			delete(s.comments, id)
+		}
+	}
+	for slug, id := range s.oldSlugs {
+		if id == postID {
+			delete(s.oldSlugs, slug)
+		}
+	}
+
+	delete(s.revisions, postID)
+	delete(s.posts, postID)
+}
+
+// purgeUser deletes the user with its posts, likes, follows and sessions. its
+// comments on other posts lose their content and author.
+func (s *store) purgeUser(userID uint) {
+	for id, post := range s.posts {
+		if post.UserRefer == userID {
+			s.purgePost(id)
+		}
+	}
+	for like := range s.likes {
+		if like.from == userID {
+			delete(s.likes, like)
+		}
+	}
+	for follow := range s.follows {
+		if follow.from == userID || follow.to == userID {
+			delete(s.follows, follow)
+		}
+	}
+	for id, session := range s.sessions {
+		if session.UserRefer == userID {
+			delete(s.sessions, id)
+		}
+	}
+
+	now := time.Now()
+	for id, comment := range s.comments {
+		if comment.UserRefer != userID {
+			continue
+		}
+		comment.Content, comment.UserRefer = "", 0
+		if !comment.DeletedAt.Valid {
+			comment.DeletedAt = gorm.DeletedAt{Time: now, Valid: true}
+		}
+		s.comments[id] = comment
+	}
+
+	delete(s.users, userID)
+}
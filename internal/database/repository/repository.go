@@ -0,0 +1,171 @@
+// Package repository describes how the app reads and writes its data, so the
+// app can run on GORM or fully in memory.
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/pooulad/blogo/internal/database/model"
+)
+
+// ErrNotFound is wrapped by every lookup which finds nothing, like
+// "user not found" and "post not found".
+var ErrNotFound = model.ErrNotFound
+
+type UserRepository interface {
+	Create(ctx context.Context, user *model.User) error
+	// GetByID returns the user with the posts viewerID can see.
+	GetByID(ctx context.Context, userID, viewerID uint) (*model.User, error)
+	GetByUsername(ctx context.Context, username string) (*model.User, error)
+	// IsEmailTaken reports whether another user than exceptUserID has the
+	// email, ignoring case.
+	IsEmailTaken(ctx context.Context, email string, exceptUserID uint) (bool, error)
+	// Update saves the fields of the user, never its posts or follows.
+	Update(ctx context.Context, user *model.User) error
+	// Delete moves the user and its posts to the trash.
+	Delete(ctx context.Context, userID uint) error
+	List(ctx context.Context, viewerID uint, query model.UserQuery) ([]model.UserResponse, *model.Page, error)
+	// Usernames maps the id of every given user to its username.
+	Usernames(ctx context.Context, userIDs []uint) (map[uint]string, error)
+	Search(ctx context.Context, query model.SearchQuery) ([]model.UserSearchResult, *model.Page, error)
+}
+
+type PostRepository interface {
+	// Create stores the post as its first revision and fills in its id and
+	// defaults.
+	Create(ctx context.Context, post *model.Post) error
+	// GetByID returns the post with its likes, tags and categories.
+	GetByID(ctx context.Context, postID uint) (*model.Post, error)
+	GetBySlug(ctx context.Context, slug string) (*model.Post, error)
+	// GetByOldSlug returns the post which had the slug before a rename.
+	GetByOldSlug(ctx context.Context, slug string) (*model.Post, error)
+	// IsSlugTaken reports whether another post than exceptPostID uses the
+	// slug now or used it before.
+	IsSlugTaken(ctx context.Context, slug string, exceptPostID uint) (bool, error)
+	// UniqueSlug slugifies the text with a numeric suffix until no other post
+	// than exceptPostID has taken it.
+	UniqueSlug(ctx context.Context, text string, exceptPostID uint) (string, error)
+	// Update saves the fields of the post as a new revision, never its likes,
+	// tags or categories. editorID is the user who made the change. a changed
+	// slug keeps the old one in the slug history.
+	Update(ctx context.Context, post *model.Post, editorID uint) error
+	// Transfer gives the post to another author.
+	Transfer(ctx context.Context, postID, userID uint) error
+	// Delete moves the post to the trash.
+	Delete(ctx context.Context, postID uint) error
+	List(ctx context.Context, viewerID uint, query model.PostQuery) ([]model.Post, *model.Page, error)
+	// HomeFeed pages through the published posts of the users userID follows.
+	HomeFeed(ctx context.Context, userID uint, query model.PageQuery) ([]model.Post, *model.Page, error)
+	// Feed returns the latest published posts of a public feed.
+	Feed(ctx context.Context, query model.FeedQuery, limit int) ([]model.Post, error)
+	Search(ctx context.Context, viewerID uint, query model.SearchQuery) ([]model.PostSearchResult, *model.Page, error)
+	Like(ctx context.Context, userID, postID uint) error
+	Unlike(ctx context.Context, userID, postID uint) error
+	IsLiked(ctx context.Context, userID, postID uint) (bool, error)
+	// LikeStats returns the like stats of every given post for the viewer.
+	LikeStats(ctx context.Context, viewerID uint, postIDs []uint) (map[uint]model.LikeStats, error)
+	// PublishScheduled publishes the scheduled posts due at now and returns
+	// how many were published.
+	PublishScheduled(ctx context.Context, now time.Time) (int64, error)
+	Revisions(ctx context.Context, postID uint, query model.PageQuery) ([]model.PostRevision, *model.Page, error)
+	Revision(ctx context.Context, postID uint, number int) (*model.PostRevision, error)
+}
+
+type FollowRepository interface {
+	Follow(ctx context.Context, followerID, followedID uint) error
+	Unfollow(ctx context.Context, followerID, followedID uint) error
+	IsFollowing(ctx context.Context, followerID, followedID uint) (bool, error)
+	// Followers pages through the users following userID.
+	Followers(ctx context.Context, userID, viewerID uint, query model.PageQuery) ([]model.UserResponse, *model.Page, error)
+	// Following pages through the users userID follows.
+	Following(ctx context.Context, userID, viewerID uint, query model.PageQuery) ([]model.UserResponse, *model.Page, error)
+}
+
+type CommentRepository interface {
+	Create(ctx context.Context, comment *model.Comment) error
+	GetByID(ctx context.Context, commentID uint) (*model.Comment, error)
+	// ListByPostID returns every comment of the post in creation order,
+	// deleted ones included so their replies keep a parent.
+	ListByPostID(ctx context.Context, postID uint) ([]model.Comment, error)
+	Update(ctx context.Context, comment *model.Comment) error
+	Delete(ctx context.Context, commentID uint) error
+	// CountByPostIDs counts the comments which aren't deleted of every given
+	// post.
+	CountByPostIDs(ctx context.Context, postIDs []uint) (map[uint]int64, error)
+}
+
+type TagRepository interface {
+	// ResolveSlug slugifies the name and follows the alias of a merged tag to
+	// the tag it was merged into.
+	ResolveSlug(ctx context.Context, name string) (string, error)
+	GetBySlug(ctx context.Context, slug string) (*model.Tag, error)
+	// FindOrCreate returns the tags with the given names, creating the ones
+	// which don't exist yet.
+	FindOrCreate(ctx context.Context, names []string) ([]model.Tag, error)
+	Attach(ctx context.Context, postID uint, tags []model.Tag) error
+	Detach(ctx context.Context, postID, tagID uint) error
+	// Counts counts the published posts of every tag.
+	Counts(ctx context.Context) ([]model.TagCount, error)
+	// Merge moves every post of from to into, deletes from and keeps its slug
+	// as an alias of into.
+	Merge(ctx context.Context, from, into *model.Tag) error
+}
+
+type CategoryRepository interface {
+	Create(ctx context.Context, category *model.Category) error
+	GetBySlug(ctx context.Context, slug string) (*model.Category, error)
+	Attach(ctx context.Context, postID uint, categories []model.Category) error
+	Detach(ctx context.Context, postID, categoryID uint) error
+	// Counts lists every category with its number of published posts.
+	Counts(ctx context.Context) ([]model.CategoryCount, error)
+}
+
+type SessionRepository interface {
+	Create(ctx context.Context, session *model.Session) error
+	GetByID(ctx context.Context, sessionID uint) (*model.Session, error)
+	// GetByTokenHash returns the session of the refresh token with its user.
+	GetByTokenHash(ctx context.Context, tokenHash string) (*model.Session, error)
+	// GetByPreviousTokenHash returns the session whose refresh token was
+	// rotated away from the token.
+	GetByPreviousTokenHash(ctx context.Context, tokenHash string) (*model.Session, error)
+	// Rotate replaces the refresh token of the session. it returns
+	// model.ErrTokenReused when another request rotated it first.
+	Rotate(ctx context.Context, session *model.Session, tokenHash string, expiresAt time.Time) error
+	Revoke(ctx context.Context, sessionID uint) error
+	RevokeByUserID(ctx context.Context, userID uint) error
+}
+
+type TrashRepository interface {
+	Posts(ctx context.Context, query model.PageQuery) ([]model.Post, *model.Page, error)
+	Users(ctx context.Context, query model.PageQuery) ([]model.UserResponse, *model.Page, error)
+	GetPost(ctx context.Context, postID uint) (*model.Post, error)
+	GetUser(ctx context.Context, userID uint) (*model.User, error)
+	RestorePost(ctx context.Context, postID uint) error
+	// RestoreUser restores the user with the posts which were trashed with
+	// it. posts the user had trashed before stay in the trash.
+	RestoreUser(ctx context.Context, user *model.User) error
+	// PurgePost deletes the post for good with every row which refers to it.
+	PurgePost(ctx context.Context, postID uint) error
+	// PurgeUser deletes the user for good with its posts, likes, follows and
+	// sessions. its comments on other posts lose their content and author.
+	PurgeUser(ctx context.Context, userID uint) error
+	// PurgeExpiredPosts purges the posts trashed before the time and returns
+	// how many were purged.
+	PurgeExpiredPosts(ctx context.Context, before time.Time) (int64, error)
+	// PurgeExpiredUsers purges the users trashed before the time and returns
+	// how many were purged.
+	PurgeExpiredUsers(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Repositories groups the repositories of one storage.
+type Repositories struct {
+	Users      UserRepository
+	Posts      PostRepository
+	Follows    FollowRepository
+	Comments   CommentRepository
+	Tags       TagRepository
+	Categories CategoryRepository
+	Sessions   SessionRepository
+	Trash      TrashRepository
+}
@@ -0,0 +1,996 @@
+// Package repositorytest is the conformance suite every implementation of the
+// repositories has to pass.
+package repositorytest
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pooulad/blogo/internal/database/model"
+	"github.com/pooulad/blogo/internal/database/repository"
+)
+
+// Run runs the suite. newRepositories is called once per test and has to
+// return repositories over an empty storage.
+func Run(t *testing.T, newRepositories func(t *testing.T) repository.Repositories) {
+	t.Run("Users", func(t *testing.T) { testUsers(t, newRepositories(t)) })
+	t.Run("UsersList", func(t *testing.T) { testUsersList(t, newRepositories(t)) })
+	t.Run("Posts", func(t *testing.T) { testPosts(t, newRepositories(t)) })
+	t.Run("PostsList", func(t *testing.T) { testPostsList(t, newRepositories(t)) })
+	t.Run("Likes", func(t *testing.T) { testLikes(t, newRepositories(t)) })
+	t.Run("PublishScheduled", func(t *testing.T) { testPublishScheduled(t, newRepositories(t)) })
+	t.Run("Follows", func(t *testing.T) { testFollows(t, newRepositories(t)) })
+	t.Run("Slugs", func(t *testing.T) { testSlugs(t, newRepositories(t)) })
+	t.Run("Revisions", func(t *testing.T) { testRevisions(t, newRepositories(t)) })
+	t.Run("Feeds", func(t *testing.T) { testFeeds(t, newRepositories(t)) })
+	t.Run("Search", func(t *testing.T) { testSearch(t, newRepositories(t)) })
+	t.Run("Comments", func(t *testing.T) { testComments(t, newRepositories(t)) })
+	t.Run("Tags", func(t *testing.T) { testTags(t, newRepositories(t)) })
+	t.Run("Categories", func(t *testing.T) { testCategories(t, newRepositories(t)) })
+	t.Run("Sessions", func(t *testing.T) { testSessions(t, newRepositories(t)) })
+	t.Run("Trash", func(t *testing.T) { testTrash(t, newRepositories(t)) })
+}
+
+func createUser(t *testing.T, repos repository.Repositories, username, role string) *model.User {
+	t.Helper()
+
+	user := &model.User{Username: username, Email: username + "@example.com", Role: role, Active: true}
+	if err := repos.Users.Create(context.Background(), user); err != nil {
+		t.Fatalf("create user %s: %v", username, err)
+	}
+	if user.ID == 0 {
+		t.Fatalf("create user %s: id is not set", username)
+	}
+
+	return user
+}
+
+func createPost(t *testing.T, repos repository.Repositories, authorID uint, title, status string) *model.Post {
+	t.Helper()
+
+	post := &model.Post{Title: title, Content: "content of " + title, Status: status, UserRefer: authorID}
+	if status == model.PostStatusPublished {
+		now := time.Now()
+		post.PublishedAt = &now
+	}
+	if err := repos.Posts.Create(context.Background(), post); err != nil {
+		t.Fatalf("create post %s: %v", title, err)
+	}
+	if post.ID == 0 {
+		t.Fatalf("create post %s: id is not set", title)
+	}
+
+	return post
+}
+
+func assertNotFound(t *testing.T, err error) {
+	t.Helper()
+
+	if !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("error = %v, want ErrNotFound", err)
+	}
+}
+
+func testUsers(t *testing.T, repos repository.Repositories) {
+	ctx := context.Background()
+	alice := createUser(t, repos, "alice", model.RoleAuthor)
+	createPost(t, repos, alice.ID, "published", model.PostStatusPublished)
+	createPost(t, repos, alice.ID, "draft", model.PostStatusDraft)
+
+	user, err := repos.Users.GetByID(ctx, alice.ID, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Username != "alice" || user.Email != "alice@example.com" {
+		t.Fatalf("got user %q %q", user.Username, user.Email)
+	}
+	if len(user.Posts) != 1 {
+		t.Fatalf("anonymous viewer sees %d posts, want 1", len(user.Posts))
+	}
+
+	user, err = repos.Users.GetByID(ctx, alice.ID, alice.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(user.Posts) != 2 {
+		t.Fatalf("author sees %d posts, want 2", len(user.Posts))
+	}
+
+	user, err = repos.Users.GetByUsername(ctx, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.ID != alice.ID {
+		t.Fatalf("got user %d, want %d", user.ID, alice.ID)
+	}
+
+	_, err = repos.Users.GetByUsername(ctx, "nobody")
+	assertNotFound(t, err)
+
+	if taken, err := repos.Users.IsEmailTaken(ctx, "Alice@Example.com", 0); err != nil || !taken {
+		t.Fatalf("IsEmailTaken of alice's email = %v, %v, want true", taken, err)
+	}
+	if taken, err := repos.Users.IsEmailTaken(ctx, "alice@example.com", alice.ID); err != nil || taken {
+		t.Fatalf("IsEmailTaken of alice's email except alice = %v, %v, want false", taken, err)
+	}
+	_, err = repos.Users.GetByID(ctx, alice.ID+100, 0)
+	assertNotFound(t, err)
+
+	user.Skill = "go"
+	if err := repos.Users.Update(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+	user, err = repos.Users.GetByID(ctx, alice.ID, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Skill != "go" {
+		t.Fatalf("skill = %q after update, want go", user.Skill)
+	}
+
+	if err := repos.Users.Delete(ctx, alice.ID); err != nil {
+		t.Fatal(err)
+	}
+	_, err = repos.Users.GetByID(ctx, alice.ID, 0)
+	assertNotFound(t, err)
+	_, err = repos.Users.GetByUsername(ctx, "alice")
+	assertNotFound(t, err)
+	assertNotFound(t, repos.Users.Delete(ctx, alice.ID))
+
+	posts, _, err := repos.Posts.List(ctx, alice.ID, model.PostQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(posts) != 0 {
+		t.Fatalf("%d posts are left after deleting their author", len(posts))
+	}
+}
+
+func testUsersList(t *testing.T, repos repository.Repositories) {
+	ctx := context.Background()
+	first := createUser(t, repos, "first", model.RoleUser)
+	createUser(t, repos, "second", model.RoleAdmin)
+	third := createUser(t, repos, "third", model.RoleUser)
+
+	users, page, err := repos.Users.List(ctx, 0, model.UserQuery{PageQuery: model.PageQuery{Limit: 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 2 || !page.HasMore || page.NextCursor == "" {
+		t.Fatalf("first page has %d users and has_more %v, want 2 and true", len(users), page.HasMore)
+	}
+	if users[0].ID != third.ID {
+		t.Fatalf("newest user is %d, want %d", users[0].ID, third.ID)
+	}
+
+	users, page, err = repos.Users.List(ctx, 0, model.UserQuery{PageQuery: model.PageQuery{Limit: 2, Cursor: page.NextCursor}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 1 || page.HasMore || users[0].ID != first.ID {
+		t.Fatalf("second page has %d users and has_more %v, want only %d", len(users), page.HasMore, first.ID)
+	}
+
+	users, _, err = repos.Users.List(ctx, 0, model.UserQuery{PageQuery: model.PageQuery{Sort: model.SortOldest}, Role: model.RoleUser})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 2 || users[0].ID != first.ID || users[1].ID != third.ID {
+		t.Fatalf("got %d users with role user, want first and third oldest first", len(users))
+	}
+
+	_, _, err = repos.Users.List(ctx, 0, model.UserQuery{PageQuery: model.PageQuery{Sort: "random"}})
+	if err == nil {
+		t.Fatal("an invalid sort is accepted")
+	}
+}
+
+func testPosts(t *testing.T, repos repository.Repositories) {
+	ctx := context.Background()
+	alice := createUser(t, repos, "alice", model.RoleAuthor)
+	post := createPost(t, repos, alice.ID, "hello", "")
+
+	// the defaults of the posts table
+	if post.Status != model.PostStatusPublished || post.Format != "markdown" {
+		t.Fatalf("new post has status %q and format %q", post.Status, post.Format)
+	}
+
+	got, err := repos.Posts.GetByID(ctx, post.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Title != "hello" || got.UserRefer != alice.ID {
+		t.Fatalf("got post %q by %d", got.Title, got.UserRefer)
+	}
+	_, err = repos.Posts.GetByID(ctx, post.ID+100)
+	assertNotFound(t, err)
+
+	got.Title = "hello again"
+	if err := repos.Posts.Update(ctx, got, alice.ID); err != nil {
+		t.Fatal(err)
+	}
+	got, err = repos.Posts.GetByID(ctx, post.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Title != "hello again" {
+		t.Fatalf("title = %q after update", got.Title)
+	}
+
+	if err := repos.Posts.Delete(ctx, post.ID); err != nil {
+		t.Fatal(err)
+	}
+	_, err = repos.Posts.GetByID(ctx, post.ID)
+	assertNotFound(t, err)
+	assertNotFound(t, repos.Posts.Delete(ctx, post.ID))
+}
+
+func testPostsList(t *testing.T, repos repository.Repositories) {
+	ctx := context.Background()
+	alice := createUser(t, repos, "alice", model.RoleAuthor)
+	bob := createUser(t, repos, "bob", model.RoleAuthor)
+	first := createPost(t, repos, alice.ID, "first", model.PostStatusPublished)
+	draft := createPost(t, repos, alice.ID, "draft", model.PostStatusDraft)
+	createPost(t, repos, bob.ID, "by bob", model.PostStatusPublished)
+
+	ids := func(posts []model.Post) map[uint]bool {
+		set := map[uint]bool{}
+		for _, post := range posts {
+			set[post.ID] = true
+		}
+		return set
+	}
+
+	posts, _, err := repos.Posts.List(ctx, 0, model.PostQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(posts) != 2 || ids(posts)[draft.ID] {
+		t.Fatalf("anonymous viewer sees %d posts, want the 2 published ones", len(posts))
+	}
+
+	posts, _, err = repos.Posts.List(ctx, alice.ID, model.PostQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(posts) != 3 || !ids(posts)[draft.ID] {
+		t.Fatalf("author sees %d posts, want 3 with the draft", len(posts))
+	}
+
+	posts, _, err = repos.Posts.List(ctx, bob.ID, model.PostQuery{AuthorID: alice.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(posts) != 1 || posts[0].ID != first.ID {
+		t.Fatalf("bob sees %d posts of alice, want only the published one", len(posts))
+	}
+
+	posts, page, err := repos.Posts.List(ctx, alice.ID, model.PostQuery{PageQuery: model.PageQuery{Limit: 2, Sort: model.SortOldest}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(posts) != 2 || !page.HasMore || posts[0].ID != first.ID {
+		t.Fatalf("first page has %d posts and has_more %v", len(posts), page.HasMore)
+	}
+
+	cursor := page.NextCursor
+	posts, page, err = repos.Posts.List(ctx, alice.ID, model.PostQuery{PageQuery: model.PageQuery{Limit: 2, Sort: model.SortOldest, Cursor: cursor}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(posts) != 1 || page.HasMore {
+		t.Fatalf("second page has %d posts and has_more %v", len(posts), page.HasMore)
+	}
+
+	_, _, err = repos.Posts.List(ctx, alice.ID, model.PostQuery{PageQuery: model.PageQuery{Sort: model.SortNewest, Cursor: cursor}})
+	if err == nil {
+		t.Fatal("a cursor of another sort is accepted")
+	}
+}
+
+func testLikes(t *testing.T, repos repository.Repositories) {
+	ctx := context.Background()
+	alice := createUser(t, repos, "alice", model.RoleAuthor)
+	bob := createUser(t, repos, "bob", model.RoleUser)
+	post := createPost(t, repos, alice.ID, "hello", model.PostStatusPublished)
+	other := createPost(t, repos, alice.ID, "other", model.PostStatusPublished)
+
+	if err := repos.Posts.Like(ctx, bob.ID, post.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := repos.Posts.Like(ctx, bob.ID, post.ID); err == nil {
+		t.Fatal("a post can be liked twice")
+	}
+
+	liked, err := repos.Posts.IsLiked(ctx, bob.ID, post.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !liked {
+		t.Fatal("liked post is not liked")
+	}
+
+	got, err := repos.Posts.GetByID(ctx, post.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.LikedBy) != 1 || got.LikedBy[0].ID != bob.ID {
+		t.Fatalf("post is liked by %d users, want bob only", len(got.LikedBy))
+	}
+
+	posts, _, err := repos.Posts.List(ctx, bob.ID, model.PostQuery{LikedByMe: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(posts) != 1 || posts[0].ID != post.ID {
+		t.Fatalf("bob liked %d posts, want 1", len(posts))
+	}
+
+	if err := repos.Posts.Unlike(ctx, bob.ID, post.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := repos.Posts.Unlike(ctx, bob.ID, other.ID); err != nil {
+		t.Fatalf("unlike a post which isn't liked: %v", err)
+	}
+
+	liked, err = repos.Posts.IsLiked(ctx, bob.ID, post.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if liked {
+		t.Fatal("unliked post is still liked")
+	}
+}
+
+func testPublishScheduled(t *testing.T, repos repository.Repositories) {
+	ctx := context.Background()
+	alice := createUser(t, repos, "alice", model.RoleAuthor)
+
+	now := time.Now()
+	due, later := now.Add(-time.Minute), now.Add(time.Hour)
+	for _, publishedAt := range []*time.Time{&due, &later} {
+		post := &model.Post{Title: "scheduled", Status: model.PostStatusScheduled, PublishedAt: publishedAt, UserRefer: alice.ID}
+		if err := repos.Posts.Create(ctx, post); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	count, err := repos.Posts.PublishScheduled(ctx, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Fatalf("published %d posts, want 1", count)
+	}
+
+	posts, _, err := repos.Posts.List(ctx, 0, model.PostQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(posts) != 1 {
+		t.Fatalf("%d posts are public, want 1", len(posts))
+	}
+}
+
+func testFollows(t *testing.T, repos repository.Repositories) {
+	ctx := context.Background()
+	alice := createUser(t, repos, "alice", model.RoleAuthor)
+	bob := createUser(t, repos, "bob", model.RoleUser)
+	carol := createUser(t, repos, "carol", model.RoleUser)
+
+	for _, follower := range []*model.User{bob, carol} {
+		if err := repos.Follows.Follow(ctx, follower.ID, alice.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := repos.Follows.Follow(ctx, bob.ID, alice.ID); err == nil {
+		t.Fatal("a user can be followed twice")
+	}
+
+	following, err := repos.Follows.IsFollowing(ctx, bob.ID, alice.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !following {
+		t.Fatal("bob doesn't follow alice")
+	}
+	following, err = repos.Follows.IsFollowing(ctx, alice.ID, bob.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if following {
+		t.Fatal("follows go both ways")
+	}
+
+	followers, page, err := repos.Follows.Followers(ctx, alice.ID, 0, model.PageQuery{Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(followers) != 1 || !page.HasMore || followers[0].ID != carol.ID {
+		t.Fatalf("first page has %d followers and has_more %v, want carol", len(followers), page.HasMore)
+	}
+
+	followers, page, err = repos.Follows.Followers(ctx, alice.ID, 0, model.PageQuery{Limit: 1, Cursor: page.NextCursor})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(followers) != 1 || page.HasMore || followers[0].ID != bob.ID {
+		t.Fatalf("second page has %d followers and has_more %v, want bob", len(followers), page.HasMore)
+	}
+
+	followed, _, err := repos.Follows.Following(ctx, bob.ID, 0, model.PageQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(followed) != 1 || followed[0].ID != alice.ID {
+		t.Fatalf("bob follows %d users, want alice", len(followed))
+	}
+
+	_, _, err = repos.Follows.Followers(ctx, alice.ID+100, 0, model.PageQuery{})
+	assertNotFound(t, err)
+
+	if err := repos.Follows.Unfollow(ctx, bob.ID, alice.ID); err != nil {
+		t.Fatal(err)
+	}
+	following, err = repos.Follows.IsFollowing(ctx, bob.ID, alice.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if following {
+		t.Fatal("bob still follows alice after unfollowing")
+	}
+
+	// deleted users drop out of follow lists
+	if err := repos.Users.Delete(ctx, carol.ID); err != nil {
+		t.Fatal(err)
+	}
+	followers, _, err = repos.Follows.Followers(ctx, alice.ID, 0, model.PageQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(followers) != 0 {
+		t.Fatalf("alice has %d followers, want none", len(followers))
+	}
+}
+
+func testSlugs(t *testing.T, repos repository.Repositories) {
+	ctx := context.Background()
+	alice := createUser(t, repos, "alice", model.RoleAuthor)
+	bob := createUser(t, repos, "bob", model.RoleAuthor)
+
+	post := &model.Post{Title: "Hello", Slug: "hello", UserRefer: alice.ID}
+	if err := repos.Posts.Create(ctx, post); err != nil {
+		t.Fatal(err)
+	}
+
+	for exceptPostID, want := range map[uint]string{0: "hello-2", post.ID: "hello"} {
+		slug, err := repos.Posts.UniqueSlug(ctx, "Hello", exceptPostID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if slug != want {
+			t.Fatalf("unique slug except post %d = %q, want %q", exceptPostID, slug, want)
+		}
+	}
+
+	post.Slug = "hi"
+	if err := repos.Posts.Update(ctx, post, alice.ID); err != nil {
+		t.Fatal(err)
+	}
+	got, err := repos.Posts.GetBySlug(ctx, "hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != post.ID {
+		t.Fatalf("slug hi is post %d, want %d", got.ID, post.ID)
+	}
+	_, err = repos.Posts.GetBySlug(ctx, "hello")
+	assertNotFound(t, err)
+
+	got, err = repos.Posts.GetByOldSlug(ctx, "hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Slug != "hi" {
+		t.Fatalf("old slug leads to slug %q, want hi", got.Slug)
+	}
+
+	// old slugs stay taken for other posts
+	for exceptPostID, want := range map[uint]bool{0: true, post.ID: false} {
+		taken, err := repos.Posts.IsSlugTaken(ctx, "hello", exceptPostID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if taken != want {
+			t.Fatalf("old slug taken except post %d = %v, want %v", exceptPostID, taken, want)
+		}
+	}
+
+	post.Slug = "hello"
+	if err := repos.Posts.Update(ctx, post, alice.ID); err != nil {
+		t.Fatal(err)
+	}
+	_, err = repos.Posts.GetByOldSlug(ctx, "hello")
+	assertNotFound(t, err)
+
+	// a failed update doesn't leave its slug change behind
+	other := &model.Post{Title: "Other", Slug: "other", UserRefer: alice.ID}
+	if err := repos.Posts.Create(ctx, other); err != nil {
+		t.Fatal(err)
+	}
+	post.Slug = "other"
+	if err := repos.Posts.Update(ctx, post, alice.ID); err == nil {
+		t.Fatal("a post is saved with the slug of another post")
+	}
+	post.Slug = "hello"
+	_, err = repos.Posts.GetByOldSlug(ctx, "hello")
+	assertNotFound(t, err)
+
+	if err := repos.Posts.Transfer(ctx, post.ID, bob.ID); err != nil {
+		t.Fatal(err)
+	}
+	got, err = repos.Posts.GetByID(ctx, post.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.UserRefer != bob.ID {
+		t.Fatalf("transferred post is by %d, want %d", got.UserRefer, bob.ID)
+	}
+}
+
+func testRevisions(t *testing.T, repos repository.Repositories) {
+	ctx := context.Background()
+	alice := createUser(t, repos, "alice", model.RoleAuthor)
+	bob := createUser(t, repos, "bob", model.RoleEditor)
+	post := createPost(t, repos, alice.ID, "hello", model.PostStatusPublished)
+
+	post.Title = "hello again"
+	if err := repos.Posts.Update(ctx, post, bob.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	revisions, _, err := repos.Posts.Revisions(ctx, post.ID, model.PageQuery{Sort: model.SortOldest})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revisions) != 2 || revisions[0].Number != 1 || revisions[1].Number != 2 {
+		t.Fatalf("post has %d revisions, want 1 and 2", len(revisions))
+	}
+
+	revision, err := repos.Posts.Revision(ctx, post.ID, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if revision.Title != "hello again" || revision.EditorRefer != bob.ID {
+		t.Fatalf("revision 2 is %q by %d", revision.Title, revision.EditorRefer)
+	}
+	_, err = repos.Posts.Revision(ctx, post.ID, 3)
+	assertNotFound(t, err)
+
+	// concurrent updates each get a number of their own
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(edit model.Post) {
+			defer wg.Done()
+			errs <- repos.Posts.Update(ctx, &edit, bob.ID)
+		}(*post)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("concurrent update: %v", err)
+		}
+	}
+
+	revisions, _, err = repos.Posts.Revisions(ctx, post.ID, model.PageQuery{Limit: 20, Sort: model.SortOldest})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, revision := range revisions {
+		if revision.Number != i+1 {
+			t.Fatalf("revision %d has number %d", i+1, revision.Number)
+		}
+	}
+	if len(revisions) != 12 {
+		t.Fatalf("post has %d revisions after 10 more updates, want 12", len(revisions))
+	}
+}
+
+func testFeeds(t *testing.T, repos repository.Repositories) {
+	ctx := context.Background()
+	alice := createUser(t, repos, "alice", model.RoleAuthor)
+	bob := createUser(t, repos, "bob", model.RoleUser)
+	carol := createUser(t, repos, "carol", model.RoleAuthor)
+	older := createPost(t, repos, alice.ID, "older", model.PostStatusPublished)
+	newer := createPost(t, repos, alice.ID, "newer", model.PostStatusPublished)
+	createPost(t, repos, alice.ID, "draft", model.PostStatusDraft)
+	createPost(t, repos, carol.ID, "not followed", model.PostStatusPublished)
+
+	if err := repos.Follows.Follow(ctx, bob.ID, alice.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := repos.Posts.Like(ctx, bob.ID, older.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	posts, _, err := repos.Posts.HomeFeed(ctx, bob.ID, model.PageQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(posts) != 2 || posts[0].ID != newer.ID || posts[1].ID != older.ID {
+		t.Fatalf("home feed has %d posts, want the 2 published posts of alice newest first", len(posts))
+	}
+
+	stats, err := repos.Posts.LikeStats(ctx, bob.ID, []uint{older.ID, newer.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !stats[older.ID].Liked || stats[older.ID].LikedCount != 1 || stats[newer.ID].LikedCount != 0 {
+		t.Fatalf("like stats = %+v", stats)
+	}
+
+	posts, err = repos.Posts.Feed(ctx, model.FeedQuery{AuthorID: alice.ID}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(posts) != 1 || posts[0].ID != newer.ID {
+		t.Fatalf("feed of alice has %d posts, want the newest one", len(posts))
+	}
+
+	usernames, err := repos.Users.Usernames(ctx, []uint{alice.ID, carol.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(usernames) != 2 || usernames[alice.ID] != "alice" || usernames[carol.ID] != "carol" {
+		t.Fatalf("usernames = %v", usernames)
+	}
+}
+
+func testSearch(t *testing.T, repos repository.Repositories) {
+	ctx := context.Background()
+	alice := createUser(t, repos, "alice", model.RoleAuthor)
+	createUser(t, repos, "bob", model.RoleUser)
+	gopher := createPost(t, repos, alice.ID, "gopher tips", model.PostStatusPublished)
+	createPost(t, repos, alice.ID, "cooking", model.PostStatusPublished)
+	createPost(t, repos, alice.ID, "gopher draft", model.PostStatusDraft)
+
+	posts, _, err := repos.Posts.Search(ctx, 0, model.SearchQuery{Q: "gopher"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(posts) != 1 || posts[0].ID != gopher.ID {
+		t.Fatalf("search found %d posts, want the published gopher one", len(posts))
+	}
+
+	users, _, err := repos.Users.Search(ctx, model.SearchQuery{Q: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 1 || users[0].ID != alice.ID {
+		t.Fatalf("search found %d users, want alice", len(users))
+	}
+}
+
+func testComments(t *testing.T, repos repository.Repositories) {
+	ctx := context.Background()
+	alice := createUser(t, repos, "alice", model.RoleAuthor)
+	post := createPost(t, repos, alice.ID, "hello", model.PostStatusPublished)
+	other := createPost(t, repos, alice.ID, "other", model.PostStatusPublished)
+
+	comment := &model.Comment{PostRefer: post.ID, UserRefer: alice.ID, Content: "first"}
+	if err := repos.Comments.Create(ctx, comment); err != nil {
+		t.Fatal(err)
+	}
+	reply := &model.Comment{PostRefer: post.ID, UserRefer: alice.ID, ParentID: &comment.ID, Content: "reply"}
+	if err := repos.Comments.Create(ctx, reply); err != nil {
+		t.Fatal(err)
+	}
+
+	reply.Content = "edited"
+	if err := repos.Comments.Update(ctx, reply); err != nil {
+		t.Fatal(err)
+	}
+	got, err := repos.Comments.GetByID(ctx, reply.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Content != "edited" {
+		t.Fatalf("content = %q after update", got.Content)
+	}
+
+	if err := repos.Comments.Delete(ctx, comment.ID); err != nil {
+		t.Fatal(err)
+	}
+	_, err = repos.Comments.GetByID(ctx, comment.ID)
+	assertNotFound(t, err)
+
+	// the deleted parent stays in the thread
+	comments, err := repos.Comments.ListByPostID(ctx, post.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(comments) != 2 || comments[0].ID != comment.ID || !comments[0].DeletedAt.Valid {
+		t.Fatalf("post has %d comments, want the deleted one first", len(comments))
+	}
+
+	counts, err := repos.Comments.CountByPostIDs(ctx, []uint{post.ID, other.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if counts[post.ID] != 1 || counts[other.ID] != 0 {
+		t.Fatalf("comment counts = %v", counts)
+	}
+}
+
+func testTags(t *testing.T, repos repository.Repositories) {
+	ctx := context.Background()
+	alice := createUser(t, repos, "alice", model.RoleAuthor)
+	post := createPost(t, repos, alice.ID, "hello", model.PostStatusPublished)
+	draft := createPost(t, repos, alice.ID, "draft", model.PostStatusDraft)
+
+	tags, err := repos.Tags.FindOrCreate(ctx, []string{"Go", "go", "  ", "Web Dev"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tags) != 2 || tags[0].Slug != "go" || tags[1].Slug != "web-dev" {
+		t.Fatalf("found %d tags, want go and web-dev", len(tags))
+	}
+	if err := repos.Tags.Attach(ctx, post.ID, tags); err != nil {
+		t.Fatal(err)
+	}
+	if err := repos.Tags.Attach(ctx, draft.ID, tags[1:]); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := repos.Posts.GetByID(ctx, post.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Tags) != 2 {
+		t.Fatalf("post has %d tags, want 2", len(got.Tags))
+	}
+
+	goTag, err := repos.Tags.GetBySlug(ctx, "go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	webDev, err := repos.Tags.GetBySlug(ctx, "web-dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repos.Tags.Merge(ctx, webDev, goTag); err != nil {
+		t.Fatal(err)
+	}
+	_, err = repos.Tags.GetBySlug(ctx, "web-dev")
+	assertNotFound(t, err)
+
+	slug, err := repos.Tags.ResolveSlug(ctx, "Web Dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if slug != "go" {
+		t.Fatalf("merged tag resolves to %q, want go", slug)
+	}
+
+	// the draft doesn't count
+	counts, err := repos.Tags.Counts(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(counts) != 1 || counts[0].Slug != "go" || counts[0].Count != 1 {
+		t.Fatalf("tag counts = %+v", counts)
+	}
+
+	if err := repos.Tags.Detach(ctx, post.ID, goTag.ID); err != nil {
+		t.Fatal(err)
+	}
+	counts, err = repos.Tags.Counts(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(counts) != 0 {
+		t.Fatalf("tag counts = %+v after detach", counts)
+	}
+}
+
+func testCategories(t *testing.T, repos repository.Repositories) {
+	ctx := context.Background()
+	alice := createUser(t, repos, "alice", model.RoleAuthor)
+	post := createPost(t, repos, alice.ID, "hello", model.PostStatusPublished)
+
+	for _, name := range []string{"News", "Art"} {
+		category := &model.Category{Name: name, Slug: strings.ToLower(name)}
+		if err := repos.Categories.Create(ctx, category); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := repos.Categories.Create(ctx, &model.Category{Name: "News again", Slug: "news"}); err == nil {
+		t.Fatal("a second category with the same slug is created")
+	}
+
+	news, err := repos.Categories.GetBySlug(ctx, "news")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = repos.Categories.GetBySlug(ctx, "sports")
+	assertNotFound(t, err)
+
+	if err := repos.Categories.Attach(ctx, post.ID, []model.Category{*news}); err != nil {
+		t.Fatal(err)
+	}
+	counts, err := repos.Categories.Counts(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(counts) != 2 || counts[0].Slug != "art" || counts[0].Count != 0 || counts[1].Count != 1 {
+		t.Fatalf("category counts = %+v", counts)
+	}
+
+	if err := repos.Categories.Detach(ctx, post.ID, news.ID); err != nil {
+		t.Fatal(err)
+	}
+	got, err := repos.Posts.GetByID(ctx, post.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Categories) != 0 {
+		t.Fatalf("post has %d categories after detach", len(got.Categories))
+	}
+}
+
+func testSessions(t *testing.T, repos repository.Repositories) {
+	ctx := context.Background()
+	alice := createUser(t, repos, "alice", model.RoleUser)
+
+	session := &model.Session{UserRefer: alice.ID, RefreshTokenHash: "first", ExpiresAt: time.Now().Add(time.Hour)}
+	if err := repos.Sessions.Create(ctx, session); err != nil {
+		t.Fatal(err)
+	}
+	other := &model.Session{UserRefer: alice.ID, RefreshTokenHash: "other", ExpiresAt: time.Now().Add(time.Hour)}
+	if err := repos.Sessions.Create(ctx, other); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := repos.Sessions.GetByTokenHash(ctx, "first")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != session.ID || got.User.Username != "alice" {
+		t.Fatalf("token is of session %d of %q", got.ID, got.User.Username)
+	}
+
+	if err := repos.Sessions.Rotate(ctx, got, "second", time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	// the session still holds the rotated token
+	if err := repos.Sessions.Rotate(ctx, session, "third", time.Now().Add(time.Hour)); !errors.Is(err, model.ErrTokenReused) {
+		t.Fatalf("rotate twice: error = %v, want ErrTokenReused", err)
+	}
+	_, err = repos.Sessions.GetByTokenHash(ctx, "first")
+	assertNotFound(t, err)
+	got, err = repos.Sessions.GetByPreviousTokenHash(ctx, "first")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.RefreshTokenHash != "second" {
+		t.Fatalf("rotated token hash = %q, want second", got.RefreshTokenHash)
+	}
+
+	if err := repos.Sessions.Revoke(ctx, session.ID); err != nil {
+		t.Fatal(err)
+	}
+	got, err = repos.Sessions.GetByID(ctx, session.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.RevokedAt == nil {
+		t.Fatal("revoked session has no revoked_at")
+	}
+
+	if err := repos.Sessions.RevokeByUserID(ctx, alice.ID); err != nil {
+		t.Fatal(err)
+	}
+	got, err = repos.Sessions.GetByID(ctx, other.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.IsActive() {
+		t.Fatal("session is active after its user logged out everywhere")
+	}
+}
+
+func testTrash(t *testing.T, repos repository.Repositories) {
+	ctx := context.Background()
+	alice := createUser(t, repos, "alice", model.RoleAuthor)
+	bob := createUser(t, repos, "bob", model.RoleUser)
+	trashedBefore := createPost(t, repos, alice.ID, "trashed before", model.PostStatusPublished)
+	post := createPost(t, repos, alice.ID, "hello", model.PostStatusPublished)
+
+	comment := &model.Comment{PostRefer: post.ID, UserRefer: bob.ID, Content: "hi"}
+	if err := repos.Comments.Create(ctx, comment); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := repos.Posts.Delete(ctx, trashedBefore.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := repos.Users.Delete(ctx, alice.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	posts, _, err := repos.Trash.Posts(ctx, model.PageQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	users, _, err := repos.Trash.Users(ctx, model.PageQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(posts) != 2 || len(users) != 1 || !users[0].DeletedAt.Valid {
+		t.Fatalf("trash has %d posts and %d users, want 2 and 1", len(posts), len(users))
+	}
+	_, err = repos.Trash.GetUser(ctx, bob.ID)
+	assertNotFound(t, err)
+
+	trashed, err := repos.Trash.GetUser(ctx, alice.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repos.Trash.RestoreUser(ctx, trashed); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repos.Posts.GetByID(ctx, post.ID); err != nil {
+		t.Fatalf("post trashed with its author: %v", err)
+	}
+	if _, err := repos.Trash.GetPost(ctx, trashedBefore.ID); err != nil {
+		t.Fatalf("post trashed before its author: %v", err)
+	}
+
+	if err := repos.Trash.PurgePost(ctx, trashedBefore.ID); err != nil {
+		t.Fatal(err)
+	}
+	_, err = repos.Trash.GetPost(ctx, trashedBefore.ID)
+	assertNotFound(t, err)
+
+	// comments of a purged user stay in the thread without content or author
+	if err := repos.Users.Delete(ctx, bob.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := repos.Trash.PurgeUser(ctx, bob.ID); err != nil {
+		t.Fatal(err)
+	}
+	_, err = repos.Trash.GetUser(ctx, bob.ID)
+	assertNotFound(t, err)
+	comments, err := repos.Comments.ListByPostID(ctx, post.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(comments) != 1 || comments[0].Content != "" || comments[0].UserRefer != 0 || !comments[0].DeletedAt.Valid {
+		t.Fatalf("comments of the purged user = %+v", comments)
+	}
+
+	if err := repos.Users.Delete(ctx, alice.ID); err != nil {
+		t.Fatal(err)
+	}
+	purged, err := repos.Trash.PurgeExpiredUsers(ctx, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if purged != 1 {
+		t.Fatalf("purged %d users, want 1", purged)
+	}
+	posts, _, err = repos.Trash.Posts(ctx, model.PageQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(posts) != 0 {
+		t.Fatalf("trash has %d posts after their author was purged", len(posts))
+	}
+}
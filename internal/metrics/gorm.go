@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const startKey = "metrics:start"
+
+// GormPlugin measures the duration and errors of every query run by gorm.
+type GormPlugin struct{}
+
+func (GormPlugin) Name() string {
+	return "metrics"
+}
+
+func (GormPlugin) Initialize(db *gorm.DB) error {
+	callback := db.Callback()
+
+	registers := []func() error{
+		func() error { return callback.Create().Before("*").Register("metrics:before_create", before) },
+		func() error { return callback.Create().After("*").Register("metrics:after_create", after("create")) },
+		func() error { return callback.Query().Before("*").Register("metrics:before_query", before) },
+		func() error { return callback.Query().After("*").Register("metrics:after_query", after("query")) },
+		func() error { return callback.Update().Before("*").Register("metrics:before_update", before) },
+		func() error { return callback.Update().After("*").Register("metrics:after_update", after("update")) },
+		func() error { return callback.Delete().Before("*").Register("metrics:before_delete", before) },
+		func() error { return callback.Delete().After("*").Register("metrics:after_delete", after("delete")) },
+		func() error { return callback.Row().Before("*").Register("metrics:before_row", before) },
+		func() error { return callback.Row().After("*").Register("metrics:after_row", after("row")) },
+		func() error { return callback.Raw().Before("*").Register("metrics:before_raw", before) },
+		func() error { return callback.Raw().After("*").Register("metrics:after_raw", after("raw")) },
+	}
+
+	for _, register := range registers {
+		if err := register(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func before(db *gorm.DB) {
+	db.InstanceSet(startKey, time.Now())
+}
+
+func after(operation string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		value, ok := db.InstanceGet(startKey)
+		if !ok {
+			return
+		}
+
+		start, ok := value.(time.Time)
+		if !ok {
+			return
+		}
+
+		table := db.Statement.Table
+		if table == "" {
+			table = "unknown"
+		}
+
+		DBQueryDuration.WithLabelValues(operation, table).Observe(time.Since(start).Seconds())
+
+		// a missing row is an answer, not a failure of the database
+		if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+			DBQueryErrors.WithLabelValues(operation, table).Inc()
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	stdhtml "html"
+	"regexp"
+	"strings"
+
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	highlighting "github.com/yuin/goldmark-highlighting/v2"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer/html"
+)
+
+const (
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+
+	// IDPrefix is put before the ids of rendered content and the fragments of
+	// links to them, so content can't clobber the ids of the page around it.
+	IDPrefix = "user-content-"
+)
+
+var (
+	markdown = goldmark.New(
+		goldmark.WithExtensions(
+			extension.GFM,
+			// classes instead of inline styles, so the sanitizer only has
+			// to allow the class attribute. clients ship a chroma theme.
+			highlighting.NewHighlighting(
+				highlighting.WithFormatOptions(chromahtml.WithClasses(true)),
+			),
+		),
+		goldmark.WithParserOptions(
+			parser.WithAutoHeadingID(),
+		),
+		// raw HTML is passed through because the output is sanitized anyway
+		goldmark.WithRendererOptions(
+			html.WithUnsafe(),
+		),
+	)
+
+	policy = newPolicy()
+	strict = bluemonday.StrictPolicy()
+
+	// the sanitizer writes every attribute as name="value" and escapes quotes
+	// in text, so these only match attributes
+	prefixIDs = strings.NewReplacer(` id="`, ` id="`+IDPrefix, ` href="#`, ` href="#`+IDPrefix)
+)
+
+// newPolicy allows what user generated content needs, plus heading ids for
+// anchors, the classes of highlighted code blocks and the checkboxes of task
+// lists.
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	p.AllowAttrs("id").Matching(regexp.MustCompile(`^[\p{L}\p{N}_-]+$`)).OnElements("h1", "h2", "h3", "h4", "h5", "h6")
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^[a-zA-Z0-9 _-]+$`)).OnElements("pre", "code", "span")
+	p.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input")
+	p.AllowAttrs("checked", "disabled").OnElements("input")
+	return p
+}
+
+// IsValidFormat reports whether content in the format can be rendered.
+func IsValidFormat(format string) bool {
+	return format == FormatMarkdown || format == FormatHTML
+}
+
+// Render turns the source into sanitized HTML which is safe to embed in a page.
+// markdown headings get ids and the ids written by authors are kept, all of
+// them start with IDPrefix.
+func Render(format, source string) (string, error) {
+	switch format {
+	case FormatMarkdown:
+		var buf bytes.Buffer
+		if err := markdown.Convert([]byte(source), &buf); err != nil {
+			return "", err
+		}
+		return prefixIDs.Replace(policy.Sanitize(buf.String())), nil
+	case FormatHTML:
+		return prefixIDs.Replace(policy.Sanitize(source)), nil
+	default:
+		return "", fmt.Errorf("format must be %s or %s", FormatMarkdown, FormatHTML)
+	}
+}
+
+// PlainText returns the text of rendered HTML without its tags, with runs of
+// whitespace collapsed. the result is not escaped.
+func PlainText(contentHTML string) string {
+	text := stdhtml.UnescapeString(strict.Sanitize(contentHTML))
+	return strings.Join(strings.Fields(text), " ")
+}
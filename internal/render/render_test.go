@@ -0,0 +1,83 @@
+package render
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		source  string
+		want    []string
+		notWant []string
+	}{
+		{
+			name:    "script in markdown",
+			format:  FormatMarkdown,
+			source:  "hello <script>alert(1)</script> <img src=x onerror=alert(1)>",
+			want:    []string{"hello", `<img src="x">`},
+			notWant: []string{"<script", "alert(1)", "onerror"},
+		},
+		{
+			name:    "script in html",
+			format:  FormatHTML,
+			source:  `<p onclick="alert(1)">hello</p><script>alert(1)</script>`,
+			want:    []string{"<p>hello</p>"},
+			notWant: []string{"<script", "onclick"},
+		},
+		{
+			name:   "task list",
+			format: FormatMarkdown,
+			source: "- [x] done\n- [ ] todo",
+			want:   []string{`<input checked="" disabled="" type="checkbox"> done`, `<input disabled="" type="checkbox"> todo`},
+		},
+		{
+			name:    "inputs other than checkboxes",
+			format:  FormatHTML,
+			source:  `<input type="password" name="password"><input type="submit"><input>`,
+			notWant: []string{"<input"},
+		},
+		{
+			name:   "markdown heading ids",
+			format: FormatMarkdown,
+			source: "# Hello world\n\n[top](#hello-world)",
+			want:   []string{`<h1 id="user-content-hello-world">`, `href="#user-content-hello-world"`},
+		},
+		{
+			name:    "html ids",
+			format:  FormatHTML,
+			source:  `<h2 id="login">Login</h2><p id="x">text</p>`,
+			want:    []string{`<h2 id="user-content-login">`, `<p id="user-content-x">text</p>`},
+			notWant: []string{`id="login"`, `id="x"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Render(tt.format, tt.source)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("Render() = %q, want it to contain %q", got, want)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(got, notWant) {
+					t.Errorf("Render() = %q, don't want %q", got, notWant)
+				}
+			}
+		})
+	}
+}
+
+func TestPlainText(t *testing.T) {
+	got := PlainText("<h1 id=\"x\">Title</h1>\n<p>a &amp; b <img src=x onerror=alert(1)></p><p>cut <a href=\"")
+	if want := "Title a & b cut"; got != want {
+		t.Errorf("PlainText() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,27 @@
+package utilities
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Slugify lowercases the text and joins its letters and digits with single
+// dashes, so "Go", " go " and "GO!" all become "go".
+func Slugify(text string) string {
+	var b strings.Builder
+	dash := false
+
+	for _, r := range strings.ToLower(text) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		dash = true
+	}
+
+	return b.String()
+}